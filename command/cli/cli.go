@@ -29,3 +29,17 @@ func (b *BasicUI) Stdout() io.Writer {
 func (b *BasicUI) Stderr() io.Writer {
 	return b.ErrorWriter
 }
+
+// StderrUI writes all messages of the wrapped UI to stderr, leaving stdout to
+// another program or to output meant for machines.
+type StderrUI struct {
+	mcli.Ui
+}
+
+func (u *StderrUI) Output(message string) {
+	u.Ui.Error(message)
+}
+
+func (u *StderrUI) Info(message string) {
+	u.Ui.Error(message)
+}
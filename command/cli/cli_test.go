@@ -151,3 +151,23 @@ func TestBasicUIInterface(t *testing.T) {
 	// Verify that BasicUI implements the UI interface
 	var _ cli.UI = &cli.BasicUI{}
 }
+
+func TestStderrUI(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+
+	ui := &cli.StderrUI{Ui: &cli.BasicUI{
+		BasicUi: mcli.BasicUi{
+			Writer:      &stdout,
+			ErrorWriter: &stderr,
+		},
+	}}
+
+	ui.Output("Output message")
+	ui.Info("Info message")
+	ui.Warn("Warning message")
+	ui.Error("Error message")
+
+	assert.Empty(t, stdout.String())
+	assert.Equal(t, "Output message\nInfo message\nWarning message\nError message\n", stderr.String())
+}
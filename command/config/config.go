@@ -81,8 +81,30 @@ Available configuration keys:
   sso.start_url        Your AWS SSO start URL
   sso.region          AWS region for SSO (e.g., us-east-1)
   sso.role            SSO role name (e.g., AdministratorAccess)
+  sso.session_name    Name of the generated [sso-session] section
+  sso.registration_scopes
+                      Comma-separated SSO registration scopes
+  sso.token_expiry_skew
+                      Ignore cached tokens expiring within this duration
   aws.default_region  Default AWS region for profiles
   aws.config_file     Path to AWS config file
+  generate.all_roles  Generate a profile for every role in every account
+  generate.profile_template
+                      Go template for generated profile names
+  generate.include    Comma-separated rules selecting accounts and roles
+  generate.exclude    Comma-separated rules selecting accounts and roles to skip
+  generate.profile_format
+                      Format of generated profiles (legacy or sso-session)
+  generate.prune      Remove generated profiles no longer returned by SSO
+  generate.dry_run    Show changes without writing the AWS config file
+  generate.confirm    Ask for confirmation before applying changes
+  generate.merge_policy
+                      Merge policy for existing profiles (overwrite,
+                      preserve-existing or only-managed-keys)
+  generate.credential_process
+                      Write credential_process into generated profiles
+  login.headless      Print the login URL and code instead of opening a browser
+  login.flow          Login flow (device-code or pkce)
 
 Examples:
   # Get the SSO start URL
@@ -6,7 +6,11 @@ import (
 	"testing"
 
 	"github.com/mitchellh/cli"
+	"github.com/mitchellh/go-homedir"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
 )
 
 func TestSetCommand(t *testing.T) {
@@ -74,6 +78,24 @@ func TestSetValidKeyValue(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestSetKeyAlias(t *testing.T) {
+	homedir.DisableCache = true
+	t.Cleanup(func() { homedir.DisableCache = false })
+	t.Setenv("HOME", t.TempDir())
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	// The short key from earlier versions sets generate.dry_run
+	exitCode := c.Run([]string{"dry_run", "true"})
+	assert.Equal(t, 0, exitCode)
+	assert.Contains(t, ui.OutputWriter.String(), "Updated generate.dry_run = true")
+
+	appCfg, err := appconfig.LoadOrDefault("")
+	require.NoError(t, err)
+	assert.True(t, appCfg.Generate.DryRun)
+}
+
 func TestSetHelp(t *testing.T) {
 	ui := cli.NewMockUi()
 	c := New(ui)
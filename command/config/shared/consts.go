@@ -5,8 +5,23 @@ const (
 	KeySSOStartURL      = "sso.start_url"
 	KeySSORegion        = "sso.region"
 	KeySSORole          = "sso.role"
+	KeySSOSessionName   = "sso.session_name"
+	KeySSOScopes        = "sso.registration_scopes"
+	KeySSOTokenSkew     = "sso.token_expiry_skew"
 	KeyAWSDefaultRegion = "aws.default_region"
 	KeyAWSConfigFile    = "aws.config_file"
+	KeyGenerateAllRoles = "generate.all_roles"
+	KeyGenerateTemplate = "generate.profile_template"
+	KeyGenerateInclude  = "generate.include"
+	KeyGenerateExclude  = "generate.exclude"
+	KeyGenerateFormat   = "generate.profile_format"
+	KeyGeneratePrune    = "generate.prune"
+	KeyGenerateDryRun   = "generate.dry_run"
+	KeyGenerateConfirm  = "generate.confirm"
+	KeyGenerateMerge    = "generate.merge_policy"
+	KeyGenerateCredProc = "generate.credential_process"
+	KeyLoginHeadless    = "login.headless"
+	KeyLoginFlow        = "login.flow"
 )
 
 // ValidKeys contains all valid configuration keys
@@ -14,8 +29,28 @@ var ValidKeys = []string{
 	KeySSOStartURL,
 	KeySSORegion,
 	KeySSORole,
+	KeySSOSessionName,
+	KeySSOScopes,
+	KeySSOTokenSkew,
 	KeyAWSDefaultRegion,
 	KeyAWSConfigFile,
+	KeyGenerateAllRoles,
+	KeyGenerateTemplate,
+	KeyGenerateInclude,
+	KeyGenerateExclude,
+	KeyGenerateFormat,
+	KeyGeneratePrune,
+	KeyGenerateDryRun,
+	KeyGenerateConfirm,
+	KeyGenerateMerge,
+	KeyGenerateCredProc,
+	KeyLoginHeadless,
+	KeyLoginFlow,
+}
+
+// KeyAliases maps the short keys accepted by earlier versions to their current keys
+var KeyAliases = map[string]string{
+	"dry_run": KeyGenerateDryRun,
 }
 
 // KeyDescriptions maps configuration keys to their descriptions
@@ -23,6 +58,21 @@ var KeyDescriptions = map[string]string{
 	KeySSOStartURL:      "Your AWS SSO start URL",
 	KeySSORegion:        "AWS region for SSO (e.g., us-east-1)",
 	KeySSORole:          "SSO role name (e.g., AdministratorAccess)",
+	KeySSOSessionName:   "Name of the generated [sso-session] section",
+	KeySSOScopes:        "Comma-separated SSO registration scopes",
+	KeySSOTokenSkew:     "Ignore cached tokens expiring within this duration (e.g. 5m)",
 	KeyAWSDefaultRegion: "Default AWS region for profiles",
 	KeyAWSConfigFile:    "Path to AWS config file",
+	KeyGenerateAllRoles: "Generate a profile for every role in every account (true/false)",
+	KeyGenerateTemplate: "Go template for generated profile names",
+	KeyGenerateInclude:  "Comma-separated rules selecting accounts and roles to generate",
+	KeyGenerateExclude:  "Comma-separated rules selecting accounts and roles to skip",
+	KeyGenerateFormat:   "Format of generated profiles (legacy or sso-session)",
+	KeyGeneratePrune:    "Remove generated profiles no longer returned by SSO (true/false)",
+	KeyGenerateDryRun:   "Show changes without writing the AWS config file (true/false)",
+	KeyGenerateConfirm:  "Ask for confirmation before applying changes (true/false)",
+	KeyGenerateMerge:    "Merge policy for existing profiles (overwrite, preserve-existing or only-managed-keys)",
+	KeyGenerateCredProc: "Write credential_process for aws-sso-config into generated profiles (true/false)",
+	KeyLoginHeadless:    "Print the login URL and code instead of opening a browser (true/false)",
+	KeyLoginFlow:        "Login flow (device-code or pkce)",
 }
@@ -1,8 +1,10 @@
 package shared
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/mitchellh/cli"
 	"github.com/stretchr/testify/assert"
 
 	appconfig "github.com/blairham/aws-sso-config/providers/config"
@@ -14,8 +16,23 @@ func TestIsValidKey(t *testing.T) {
 		"sso.start_url",
 		"sso.region",
 		"sso.role",
+		"sso.session_name",
+		"sso.registration_scopes",
+		"sso.token_expiry_skew",
 		"aws.default_region",
 		"aws.config_file",
+		"generate.all_roles",
+		"generate.profile_template",
+		"generate.include",
+		"generate.exclude",
+		"generate.profile_format",
+		"generate.prune",
+		"generate.dry_run",
+		"generate.confirm",
+		"generate.merge_policy",
+		"generate.credential_process",
+		"login.headless",
+		"login.flow",
 	}
 
 	for _, key := range validKeys {
@@ -37,15 +54,30 @@ func TestIsValidKey(t *testing.T) {
 
 func TestValidKeysConstant(t *testing.T) {
 	// Verify that ValidKeys has the expected number of keys
-	assert.Len(t, ValidKeys, 5, "ValidKeys should contain 5 keys")
+	assert.Len(t, ValidKeys, 20, "ValidKeys should contain 20 keys")
 
 	// Verify all expected keys are present
 	expectedKeys := map[string]bool{
-		"sso.start_url":      true,
-		"sso.region":         true,
-		"sso.role":           true,
-		"aws.default_region": true,
-		"aws.config_file":    true,
+		"sso.start_url":               true,
+		"sso.region":                  true,
+		"sso.role":                    true,
+		"sso.session_name":            true,
+		"sso.registration_scopes":     true,
+		"sso.token_expiry_skew":       true,
+		"aws.default_region":          true,
+		"aws.config_file":             true,
+		"generate.all_roles":          true,
+		"generate.profile_template":   true,
+		"generate.include":            true,
+		"generate.exclude":            true,
+		"generate.profile_format":     true,
+		"generate.prune":              true,
+		"generate.dry_run":            true,
+		"generate.confirm":            true,
+		"generate.merge_policy":       true,
+		"generate.credential_process": true,
+		"login.headless":              true,
+		"login.flow":                  true,
 	}
 
 	for _, key := range ValidKeys {
@@ -62,14 +94,58 @@ func TestKeyDescriptions(t *testing.T) {
 	}
 }
 
+func TestOutputAvailableKeys(t *testing.T) {
+	ui := cli.NewMockUi()
+	OutputAvailableKeys(ui)
+
+	lines := strings.Split(strings.TrimSuffix(ui.OutputWriter.String(), "\n"), "\n")
+	assert.Len(t, lines, len(ValidKeys))
+
+	// Every description starts in the same column, after the longest key
+	longest := 0
+	for _, key := range ValidKeys {
+		longest = max(longest, len(key))
+	}
+	column := strings.Index(lines[0], KeyDescriptions[ValidKeys[0]])
+	assert.Equal(t, len("  ")+longest+len(" "), column)
+	for i, key := range ValidKeys {
+		assert.Equal(t, column, strings.Index(lines[i], KeyDescriptions[key]), key)
+		assert.True(t, strings.HasPrefix(lines[i], "  "+key+" "), key)
+	}
+}
+
+func TestPrintAvailableKeys(t *testing.T) {
+	ui := cli.NewMockUi()
+	PrintAvailableKeys(ui)
+
+	lines := strings.Split(strings.TrimSuffix(ui.ErrorWriter.String(), "\n"), "\n")
+	assert.Equal(t, "Available keys:", lines[0])
+	assert.Equal(t, availableKeyLines(), lines[1:])
+}
+
 func TestGetConfigValue(t *testing.T) {
 	// Create a test config
 	config := &appconfig.Config{}
 	config.SSO.StartURL = "https://test.awsapps.com/start"
 	config.SSO.Region = "us-west-2"
 	config.SSO.Role = "TestRole"
+	config.SSO.SessionName = "my-org"
+	config.SSO.RegistrationScopes = "sso:account:access"
+	config.SSO.TokenExpirySkew = "5m"
 	config.AWS.DefaultRegion = "us-east-1"
 	config.AWS.ConfigFile = "/test/config"
+	config.Generate.AllRoles = true
+	config.Generate.ProfileTemplate = "{{ .AccountName }}"
+	config.Generate.Include = []string{"prod-*", "role:ReadOnly"}
+	config.Generate.Exclude = []string{"id:123456789012"}
+	config.Generate.ProfileFormat = "sso-session"
+	config.Generate.Prune = true
+	config.Generate.DryRun = true
+	config.Generate.Confirm = true
+	config.Generate.MergePolicy = "only-managed-keys"
+	config.Generate.CredentialProcess = true
+	config.Login.Headless = true
+	config.Login.Flow = "pkce"
 
 	tests := []struct {
 		key      string
@@ -78,8 +154,23 @@ func TestGetConfigValue(t *testing.T) {
 		{KeySSOStartURL, "https://test.awsapps.com/start"},
 		{KeySSORegion, "us-west-2"},
 		{KeySSORole, "TestRole"},
+		{KeySSOSessionName, "my-org"},
+		{KeySSOScopes, "sso:account:access"},
+		{KeySSOTokenSkew, "5m"},
 		{KeyAWSDefaultRegion, "us-east-1"},
 		{KeyAWSConfigFile, "/test/config"},
+		{KeyGenerateAllRoles, "true"},
+		{KeyGenerateTemplate, "{{ .AccountName }}"},
+		{KeyGenerateInclude, "prod-*,role:ReadOnly"},
+		{KeyGenerateExclude, "id:123456789012"},
+		{KeyGenerateFormat, "sso-session"},
+		{KeyGeneratePrune, "true"},
+		{KeyGenerateDryRun, "true"},
+		{KeyGenerateConfirm, "true"},
+		{KeyGenerateMerge, "only-managed-keys"},
+		{KeyGenerateCredProc, "true"},
+		{KeyLoginHeadless, "true"},
+		{KeyLoginFlow, "pkce"},
 	}
 
 	for _, tt := range tests {
@@ -106,8 +197,23 @@ func TestSetConfigValue(t *testing.T) {
 		{KeySSOStartURL, "https://new.awsapps.com/start"},
 		{KeySSORegion, "eu-west-1"},
 		{KeySSORole, "NewRole"},
+		{KeySSOSessionName, "new-org"},
+		{KeySSOScopes, "sso:account:access,sso-portal:*"},
+		{KeySSOTokenSkew, "10m"},
 		{KeyAWSDefaultRegion, "ap-south-1"},
 		{KeyAWSConfigFile, "/new/config"},
+		{KeyGenerateAllRoles, "true"},
+		{KeyGenerateTemplate, "{{ .AccountName | lower }}-{{ .RoleName }}"},
+		{KeyGenerateInclude, "prod-*,role:ReadOnly"},
+		{KeyGenerateExclude, "sandbox-*"},
+		{KeyGenerateFormat, "legacy"},
+		{KeyGeneratePrune, "true"},
+		{KeyGenerateDryRun, "true"},
+		{KeyGenerateConfirm, "true"},
+		{KeyGenerateMerge, "preserve-existing"},
+		{KeyGenerateCredProc, "true"},
+		{KeyLoginHeadless, "true"},
+		{KeyLoginFlow, "device-code"},
 	}
 
 	for _, tt := range tests {
@@ -126,16 +232,63 @@ func TestSetConfigValue(t *testing.T) {
 	err := SetConfigValue(config, "invalid_key", "test_value")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unknown configuration key")
+
+	// Test invalid boolean value
+	err = SetConfigValue(config, KeyGenerateAllRoles, "sometimes")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is not a boolean")
+
+	err = SetConfigValue(config, KeyGeneratePrune, "maybe")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is not a boolean")
+
+	// Test list values are split and validated
+	err = SetConfigValue(config, KeyGenerateExclude, " sandbox-* , id:123456789012 ,")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sandbox-*", "id:123456789012"}, config.Generate.Exclude)
+
+	err = SetConfigValue(config, KeyGenerateInclude, "role:/(/")
+	assert.Error(t, err)
+
+	// Test invalid profile format
+	err = SetConfigValue(config, KeyGenerateFormat, "modern")
+	assert.Error(t, err)
+
+	// Test invalid token expiry skew
+	err = SetConfigValue(config, KeySSOTokenSkew, "soon")
+	assert.Error(t, err)
+	err = SetConfigValue(config, KeySSOTokenSkew, "-1m")
+	assert.Error(t, err)
+
+	// Test invalid merge policy
+	err = SetConfigValue(config, KeyGenerateMerge, "merge")
+	assert.Error(t, err)
+
+	// Test invalid login flow
+	err = SetConfigValue(config, KeyLoginFlow, "implicit")
+	assert.Error(t, err)
 }
 
 func TestAllValidKeysHaveConstants(t *testing.T) {
 	// Ensure all valid keys have corresponding constants
 	expectedConstants := map[string]string{
-		"sso.start_url":      KeySSOStartURL,
-		"sso.region":         KeySSORegion,
-		"sso.role":           KeySSORole,
-		"aws.default_region": KeyAWSDefaultRegion,
-		"aws.config_file":    KeyAWSConfigFile,
+		"sso.start_url":               KeySSOStartURL,
+		"sso.region":                  KeySSORegion,
+		"sso.role":                    KeySSORole,
+		"aws.default_region":          KeyAWSDefaultRegion,
+		"aws.config_file":             KeyAWSConfigFile,
+		"generate.all_roles":          KeyGenerateAllRoles,
+		"generate.profile_template":   KeyGenerateTemplate,
+		"generate.include":            KeyGenerateInclude,
+		"generate.exclude":            KeyGenerateExclude,
+		"generate.profile_format":     KeyGenerateFormat,
+		"generate.prune":              KeyGeneratePrune,
+		"generate.dry_run":            KeyGenerateDryRun,
+		"generate.confirm":            KeyGenerateConfirm,
+		"generate.merge_policy":       KeyGenerateMerge,
+		"generate.credential_process": KeyGenerateCredProc,
+		"login.headless":              KeyLoginHeadless,
+		"login.flow":                  KeyLoginFlow,
 	}
 
 	for validKey, expectedConstant := range expectedConstants {
@@ -149,6 +302,21 @@ func TestKeyConstantsMatch(t *testing.T) {
 	assert.Equal(t, "sso.start_url", KeySSOStartURL)
 	assert.Equal(t, "sso.region", KeySSORegion)
 	assert.Equal(t, "sso.role", KeySSORole)
+	assert.Equal(t, "sso.session_name", KeySSOSessionName)
+	assert.Equal(t, "sso.registration_scopes", KeySSOScopes)
+	assert.Equal(t, "sso.token_expiry_skew", KeySSOTokenSkew)
 	assert.Equal(t, "aws.default_region", KeyAWSDefaultRegion)
 	assert.Equal(t, "aws.config_file", KeyAWSConfigFile)
+	assert.Equal(t, "generate.all_roles", KeyGenerateAllRoles)
+	assert.Equal(t, "generate.profile_template", KeyGenerateTemplate)
+	assert.Equal(t, "generate.include", KeyGenerateInclude)
+	assert.Equal(t, "generate.exclude", KeyGenerateExclude)
+	assert.Equal(t, "generate.profile_format", KeyGenerateFormat)
+	assert.Equal(t, "generate.prune", KeyGeneratePrune)
+	assert.Equal(t, "generate.dry_run", KeyGenerateDryRun)
+	assert.Equal(t, "generate.confirm", KeyGenerateConfirm)
+	assert.Equal(t, "generate.merge_policy", KeyGenerateMerge)
+	assert.Equal(t, "generate.credential_process", KeyGenerateCredProc)
+	assert.Equal(t, "login.headless", KeyLoginHeadless)
+	assert.Equal(t, "login.flow", KeyLoginFlow)
 }
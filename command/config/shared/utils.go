@@ -2,6 +2,9 @@ package shared
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	appconfig "github.com/blairham/aws-sso-config/providers/config"
 )
@@ -16,6 +19,14 @@ func IsValidKey(key string) bool {
 	return false
 }
 
+// CanonicalKey returns the current key for an alias, or the key unchanged
+func CanonicalKey(key string) string {
+	if canonical, ok := KeyAliases[key]; ok {
+		return canonical
+	}
+	return key
+}
+
 // GetConfigValue gets the value for the specified key from the config
 func GetConfigValue(config *appconfig.Config, key string) (string, error) {
 	switch key {
@@ -25,10 +36,40 @@ func GetConfigValue(config *appconfig.Config, key string) (string, error) {
 		return config.SSO.Region, nil
 	case KeySSORole:
 		return config.SSO.Role, nil
+	case KeySSOSessionName:
+		return config.SSO.SessionName, nil
+	case KeySSOScopes:
+		return config.SSO.RegistrationScopes, nil
+	case KeySSOTokenSkew:
+		return config.SSO.TokenExpirySkew, nil
 	case KeyAWSDefaultRegion:
 		return config.AWS.DefaultRegion, nil
 	case KeyAWSConfigFile:
 		return config.AWS.ConfigFile, nil
+	case KeyGenerateAllRoles:
+		return strconv.FormatBool(config.Generate.AllRoles), nil
+	case KeyGenerateTemplate:
+		return config.Generate.ProfileTemplate, nil
+	case KeyGenerateInclude:
+		return strings.Join(config.Generate.Include, ","), nil
+	case KeyGenerateExclude:
+		return strings.Join(config.Generate.Exclude, ","), nil
+	case KeyGenerateFormat:
+		return config.Generate.ProfileFormat, nil
+	case KeyGeneratePrune:
+		return strconv.FormatBool(config.Generate.Prune), nil
+	case KeyGenerateDryRun:
+		return strconv.FormatBool(config.Generate.DryRun), nil
+	case KeyGenerateConfirm:
+		return strconv.FormatBool(config.Generate.Confirm), nil
+	case KeyGenerateMerge:
+		return config.Generate.MergePolicy, nil
+	case KeyGenerateCredProc:
+		return strconv.FormatBool(config.Generate.CredentialProcess), nil
+	case KeyLoginHeadless:
+		return strconv.FormatBool(config.Login.Headless), nil
+	case KeyLoginFlow:
+		return config.Login.Flow, nil
 	default:
 		return "", fmt.Errorf("unknown configuration key: %s", key)
 	}
@@ -36,48 +77,180 @@ func GetConfigValue(config *appconfig.Config, key string) (string, error) {
 
 // SetConfigValue sets the value for the specified key in the config
 func SetConfigValue(config *appconfig.Config, key string, value string) error {
+	section, _, _ := strings.Cut(key, ".")
+	switch section {
+	case "sso":
+		return setSSOValue(&config.SSO, key, value)
+	case "aws":
+		return setAWSValue(&config.AWS, key, value)
+	case "generate":
+		return setGenerateValue(&config.Generate, key, value)
+	case "login":
+		return setLoginValue(&config.Login, key, value)
+	default:
+		return fmt.Errorf("unknown configuration key: %s", key)
+	}
+}
+
+// setSSOValue sets the value for a key in the sso section
+func setSSOValue(sso *appconfig.SSOConfig, key, value string) error {
 	switch key {
 	case KeySSOStartURL:
-		config.SSO.StartURL = value
-		return nil
+		sso.StartURL = value
 	case KeySSORegion:
-		config.SSO.Region = value
-		return nil
+		sso.Region = value
 	case KeySSORole:
-		config.SSO.Role = value
-		return nil
+		sso.Role = value
+	case KeySSOSessionName:
+		sso.SessionName = value
+	case KeySSOScopes:
+		sso.RegistrationScopes = value
+	case KeySSOTokenSkew:
+		if skew, err := time.ParseDuration(value); err != nil || skew < 0 {
+			return fmt.Errorf("invalid value for %s: %q is not a non-negative duration", key, value)
+		}
+		sso.TokenExpirySkew = value
+	default:
+		return fmt.Errorf("unknown configuration key: %s", key)
+	}
+	return nil
+}
+
+// setAWSValue sets the value for a key in the aws section
+func setAWSValue(aws *appconfig.AWSConfig, key, value string) error {
+	switch key {
 	case KeyAWSDefaultRegion:
-		config.AWS.DefaultRegion = value
-		return nil
+		aws.DefaultRegion = value
 	case KeyAWSConfigFile:
-		config.AWS.ConfigFile = value
+		aws.ConfigFile = value
+	default:
+		return fmt.Errorf("unknown configuration key: %s", key)
+	}
+	return nil
+}
+
+// setGenerateValue sets the value for a key in the generate section
+func setGenerateValue(generate *appconfig.GenerateConfig, key, value string) error {
+	bools := map[string]*bool{
+		KeyGenerateAllRoles: &generate.AllRoles,
+		KeyGeneratePrune:    &generate.Prune,
+		KeyGenerateDryRun:   &generate.DryRun,
+		KeyGenerateConfirm:  &generate.Confirm,
+		KeyGenerateCredProc: &generate.CredentialProcess,
+	}
+	if field, ok := bools[key]; ok {
+		b, err := parseBool(key, value)
+		if err != nil {
+			return err
+		}
+		*field = b
 		return nil
+	}
+
+	switch key {
+	case KeyGenerateMerge:
+		switch value {
+		case appconfig.MergePolicyOverwrite, appconfig.MergePolicyPreserveExisting, appconfig.MergePolicyOnlyManagedKeys:
+		default:
+			return fmt.Errorf("invalid value for %s: must be %q, %q or %q", key,
+				appconfig.MergePolicyOverwrite, appconfig.MergePolicyPreserveExisting, appconfig.MergePolicyOnlyManagedKeys)
+		}
+		generate.MergePolicy = value
+	case KeyGenerateTemplate:
+		generate.ProfileTemplate = value
+	case KeyGenerateInclude, KeyGenerateExclude:
+		rules := splitList(value)
+		if _, err := appconfig.ParseFilterRules(rules); err != nil {
+			return err
+		}
+		if key == KeyGenerateInclude {
+			generate.Include = rules
+		} else {
+			generate.Exclude = rules
+		}
+	case KeyGenerateFormat:
+		if value != appconfig.ProfileFormatLegacy && value != appconfig.ProfileFormatSSOSession {
+			return fmt.Errorf("invalid value for %s: must be %q or %q", key, appconfig.ProfileFormatLegacy, appconfig.ProfileFormatSSOSession)
+		}
+		generate.ProfileFormat = value
 	default:
 		return fmt.Errorf("unknown configuration key: %s", key)
 	}
+	return nil
+}
+
+// setLoginValue sets the value for a key in the login section
+func setLoginValue(login *appconfig.LoginConfig, key, value string) error {
+	switch key {
+	case KeyLoginHeadless:
+		headless, err := parseBool(key, value)
+		if err != nil {
+			return err
+		}
+		login.Headless = headless
+	case KeyLoginFlow:
+		if value != appconfig.LoginFlowDeviceCode && value != appconfig.LoginFlowPKCE {
+			return fmt.Errorf("invalid value for %s: must be %q or %q", key, appconfig.LoginFlowDeviceCode, appconfig.LoginFlowPKCE)
+		}
+		login.Flow = value
+	default:
+		return fmt.Errorf("unknown configuration key: %s", key)
+	}
+	return nil
+}
+
+// parseBool parses a boolean configuration value
+func parseBool(key, value string) (bool, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid value for %s: %q is not a boolean", key, value)
+	}
+	return b, nil
+}
+
+// splitList splits a comma-separated value into its non-empty elements
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
 }
 
 // PrintAvailableKeys prints all available configuration keys with descriptions to Error
 func PrintAvailableKeys(ui interface{ Error(string) }) {
 	ui.Error("Available keys:")
-	for _, key := range ValidKeys {
-		if desc, ok := KeyDescriptions[key]; ok {
-			ui.Error(fmt.Sprintf("  %-16s %s", key, desc))
-		} else {
-			ui.Error(fmt.Sprintf("  %s", key))
-		}
+	for _, line := range availableKeyLines() {
+		ui.Error(line)
 	}
 }
 
 // OutputAvailableKeys prints all available configuration keys with descriptions to Output
 func OutputAvailableKeys(ui interface{ Output(string) }) {
+	for _, line := range availableKeyLines() {
+		ui.Output(line)
+	}
+}
+
+// availableKeyLines returns a line for each configuration key, with the
+// descriptions aligned after the longest key
+func availableKeyLines() []string {
+	width := 0
+	for _, key := range ValidKeys {
+		width = max(width, len(key))
+	}
+
+	lines := make([]string, 0, len(ValidKeys))
 	for _, key := range ValidKeys {
 		if desc, ok := KeyDescriptions[key]; ok {
-			ui.Output(fmt.Sprintf("  %-16s %s", key, desc))
+			lines = append(lines, fmt.Sprintf("  %-*s %s", width, key, desc))
 		} else {
-			ui.Output(fmt.Sprintf("  %s", key))
+			lines = append(lines, fmt.Sprintf("  %s", key))
 		}
 	}
+	return lines
 }
 
 // SaveConfigValue saves a configuration value to the configuration file
@@ -102,12 +275,28 @@ func SaveConfigValue(configFile string, key string, value string) error {
 	case KeySSORole:
 		config.SSO.Role = value
 		err = cm.SaveProviderConfig("sso", config.SSO)
+	case KeySSOSessionName, KeySSOScopes, KeySSOTokenSkew:
+		if err = SetConfigValue(config, key, value); err != nil {
+			return err
+		}
+		err = cm.SaveProviderConfig("sso", config.SSO)
 	case KeyAWSDefaultRegion:
 		config.AWS.DefaultRegion = value
 		err = cm.SaveProviderConfig("aws", config.AWS)
 	case KeyAWSConfigFile:
 		config.AWS.ConfigFile = value
 		err = cm.SaveProviderConfig("aws", config.AWS)
+	case KeyGenerateAllRoles, KeyGenerateTemplate, KeyGenerateInclude, KeyGenerateExclude, KeyGenerateFormat,
+		KeyGeneratePrune, KeyGenerateDryRun, KeyGenerateConfirm, KeyGenerateMerge, KeyGenerateCredProc:
+		if err = SetConfigValue(config, key, value); err != nil {
+			return err
+		}
+		err = cm.SaveProviderConfig("generate", config.Generate)
+	case KeyLoginHeadless, KeyLoginFlow:
+		if err = SetConfigValue(config, key, value); err != nil {
+			return err
+		}
+		err = cm.SaveProviderConfig("login", config.Login)
 	default:
 		return fmt.Errorf("unknown configuration key: %s", key)
 	}
@@ -2,6 +2,8 @@ package unset
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/mitchellh/cli"
 
@@ -25,7 +27,7 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
-	key := args[0]
+	key := shared.CanonicalKey(args[0])
 
 	// Validate the key
 	if !shared.IsValidKey(key) {
@@ -74,10 +76,40 @@ func (c *cmd) getDefaultValue(key string) (string, error) {
 		return appconfig.DefaultSSO().Region, nil
 	case shared.KeySSORole:
 		return appconfig.DefaultSSO().Role, nil
+	case shared.KeySSOSessionName:
+		return appconfig.DefaultSSO().SessionName, nil
+	case shared.KeySSOScopes:
+		return appconfig.DefaultSSO().RegistrationScopes, nil
+	case shared.KeySSOTokenSkew:
+		return appconfig.DefaultSSO().TokenExpirySkew, nil
 	case shared.KeyAWSDefaultRegion:
 		return appconfig.DefaultAWS().DefaultRegion, nil
 	case shared.KeyAWSConfigFile:
 		return appconfig.DefaultAWS().ConfigFile, nil
+	case shared.KeyGenerateAllRoles:
+		return strconv.FormatBool(appconfig.DefaultGenerate().AllRoles), nil
+	case shared.KeyGenerateTemplate:
+		return appconfig.DefaultGenerate().ProfileTemplate, nil
+	case shared.KeyGenerateInclude:
+		return strings.Join(appconfig.DefaultGenerate().Include, ","), nil
+	case shared.KeyGenerateExclude:
+		return strings.Join(appconfig.DefaultGenerate().Exclude, ","), nil
+	case shared.KeyGenerateFormat:
+		return appconfig.DefaultGenerate().ProfileFormat, nil
+	case shared.KeyGeneratePrune:
+		return strconv.FormatBool(appconfig.DefaultGenerate().Prune), nil
+	case shared.KeyGenerateDryRun:
+		return strconv.FormatBool(appconfig.DefaultGenerate().DryRun), nil
+	case shared.KeyGenerateConfirm:
+		return strconv.FormatBool(appconfig.DefaultGenerate().Confirm), nil
+	case shared.KeyGenerateMerge:
+		return appconfig.DefaultGenerate().MergePolicy, nil
+	case shared.KeyGenerateCredProc:
+		return strconv.FormatBool(appconfig.DefaultGenerate().CredentialProcess), nil
+	case shared.KeyLoginHeadless:
+		return strconv.FormatBool(appconfig.DefaultLogin().Headless), nil
+	case shared.KeyLoginFlow:
+		return appconfig.DefaultLogin().Flow, nil
 	default:
 		return "", fmt.Errorf("unknown configuration key: %s", key)
 	}
@@ -95,8 +127,30 @@ Available configuration keys:
   sso.start_url        Your AWS SSO start URL
   sso.region          AWS region for SSO (e.g., us-east-1)
   sso.role            SSO role name (e.g., AdministratorAccess)
+  sso.session_name    Name of the generated [sso-session] section
+  sso.registration_scopes
+                      Comma-separated SSO registration scopes
+  sso.token_expiry_skew
+                      Ignore cached tokens expiring within this duration
   aws.default_region  Default AWS region for profiles
   aws.config_file     Path to AWS config file
+  generate.all_roles  Generate a profile for every role in every account
+  generate.profile_template
+                      Go template for generated profile names
+  generate.include    Comma-separated rules selecting accounts and roles
+  generate.exclude    Comma-separated rules selecting accounts and roles to skip
+  generate.profile_format
+                      Format of generated profiles (legacy or sso-session)
+  generate.prune      Remove generated profiles no longer returned by SSO
+  generate.dry_run    Show changes without writing the AWS config file
+  generate.confirm    Ask for confirmation before applying changes
+  generate.merge_policy
+                      Merge policy for existing profiles (overwrite,
+                      preserve-existing or only-managed-keys)
+  generate.credential_process
+                      Write credential_process into generated profiles
+  login.headless      Print the login URL and code instead of opening a browser
+  login.flow          Login flow (device-code or pkce)
 
 Examples:
   # Reset SSO start URL to default
@@ -101,8 +101,23 @@ func TestCmd_getDefaultValue(t *testing.T) {
 		{shared.KeySSOStartURL, appconfig.DefaultSSO().StartURL, false},
 		{shared.KeySSORegion, appconfig.DefaultSSO().Region, false},
 		{shared.KeySSORole, appconfig.DefaultSSO().Role, false},
+		{shared.KeySSOSessionName, "aws-sso-config", false},
+		{shared.KeySSOScopes, "sso:account:access", false},
+		{shared.KeySSOTokenSkew, "5m", false},
 		{shared.KeyAWSDefaultRegion, appconfig.DefaultAWS().DefaultRegion, false},
 		{shared.KeyAWSConfigFile, appconfig.DefaultAWS().ConfigFile, false},
+		{shared.KeyGenerateAllRoles, "false", false},
+		{shared.KeyGenerateTemplate, "", false},
+		{shared.KeyGenerateInclude, "", false},
+		{shared.KeyGenerateExclude, "", false},
+		{shared.KeyGenerateFormat, "legacy", false},
+		{shared.KeyGeneratePrune, "false", false},
+		{shared.KeyGenerateDryRun, "false", false},
+		{shared.KeyGenerateConfirm, "false", false},
+		{shared.KeyGenerateMerge, "overwrite", false},
+		{shared.KeyGenerateCredProc, "false", false},
+		{shared.KeyLoginHeadless, "false", false},
+		{shared.KeyLoginFlow, "device-code", false},
 		{"invalid.key", "", true},
 	}
 
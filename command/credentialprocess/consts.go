@@ -0,0 +1,34 @@
+package credentialprocess
+
+const synopsis = "Print role credentials for credential_process"
+const help = `
+Usage: aws-sso-config credential-process -profile=<name> [options]
+
+  This command prints temporary credentials for the role of an SSO
+  profile of the AWS config file in the JSON format AWS SDKs and
+  tools expect from a credential_process, so that tools which do not
+  support SSO profiles can use them:
+
+    [profile legacy-tool]
+    credential_process = aws-sso-config credential-process --profile prod
+
+  The cached SSO token is used, and refreshed when it has expired. You
+  are only asked to log in when there is no token to refresh; login
+  messages are written to stderr.
+
+  generate writes credential_process into every generated profile when
+  run with -credential-process or generate.credential_process is set.
+
+Options:
+
+  -profile=<name>   Profile of the AWS config file to get credentials for.
+                    Required.
+
+  -config=<path>    Path to configuration file. If not specified,
+                    uses environment variables and defaults.
+
+Examples:
+
+  # Print credentials for the prod profile
+  aws-sso-config credential-process -profile=prod
+`
@@ -0,0 +1,115 @@
+package credentialprocess
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/mitchellh/cli"
+
+	commandcli "github.com/blairham/aws-sso-config/command/cli"
+	"github.com/blairham/aws-sso-config/command/flags"
+	"github.com/blairham/aws-sso-config/command/internal/rolecreds"
+	awsprovider "github.com/blairham/aws-sso-config/providers/aws"
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// processCredentials is the output of a credential_process, as documented in
+// "Sourcing credentials with an external process" of the AWS SDKs reference
+type processCredentials struct {
+	Version         int
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      string
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	help  string
+
+	profile    string
+	configFile string
+
+	// Dependencies for testing
+	credentials rolecreds.Source
+}
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui, credentials: rolecreds.New()}
+	c.Init()
+	return c
+}
+
+// NewWithDependencies creates a new command with injected dependencies for testing
+func NewWithDependencies(
+	ui cli.Ui,
+	ssoClientFactory func(aws.Config) awsprovider.RoleCredentialsClient,
+	newAuthenticator func(ui cli.Ui, cfg aws.Config) awsprovider.Authenticator,
+	configLoader func() (aws.Config, error),
+) *cmd {
+	c := &cmd{UI: ui, credentials: rolecreds.Source{
+		SSOClientFactory: ssoClientFactory,
+		NewAuthenticator: newAuthenticator,
+		ConfigLoader:     configLoader,
+	}}
+	c.Init()
+	return c
+}
+
+func (c *cmd) Init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.StringVar(&c.profile, "profile", "", "Profile of the AWS config file to get credentials for.")
+	c.flags.StringVar(&c.configFile, "config", "", "Path to configuration file.")
+
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+	if c.profile == "" {
+		c.UI.Error("Usage: aws-sso-config credential-process -profile=<name>")
+		return 1
+	}
+
+	appCfg, err := appconfig.LoadOrDefault(c.configFile)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Configuration error: %v", err))
+		return 1
+	}
+
+	// Stdout is read by the SDK, so login progress goes to stderr
+	_, creds, err := c.credentials.Get(&commandcli.StderrUI{Ui: c.UI}, appCfg, c.profile)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error getting credentials for profile %s: %v", c.profile, err))
+		return 1
+	}
+
+	output, err := json.Marshal(processCredentials{
+		Version:         1,
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		Expiration:      creds.Expiration.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error encoding credentials: %v", err))
+		return 1
+	}
+
+	c.UI.Output(string(output))
+	return 0
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
@@ -0,0 +1,149 @@
+package credentialprocess
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	awsprovider "github.com/blairham/aws-sso-config/providers/aws"
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// Mock implementation of RoleCredentialsClient interface for testing
+type MockRoleCredentialsClient struct {
+	mock.Mock
+}
+
+func (m *MockRoleCredentialsClient) GetRoleCredentials(
+	ctx context.Context,
+	params *sso.GetRoleCredentialsInput,
+	optFns ...func(*sso.Options),
+) (*sso.GetRoleCredentialsOutput, error) {
+	args := m.Called(ctx, params)
+	result := args.Get(0)
+	if result == nil {
+		return nil, args.Error(1)
+	}
+	return result.(*sso.GetRoleCredentialsOutput), args.Error(1)
+}
+
+// MockAuthenticator returns a fixed token or error, showing a login message
+type MockAuthenticator struct {
+	ui    cli.Ui
+	token string
+	err   error
+}
+
+func (m *MockAuthenticator) Authenticate(context.Context, *appconfig.Config) (*string, error) {
+	m.ui.Output("Waiting for authorization...")
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &m.token, nil
+}
+
+// testCommand returns a command using an AWS config file with a prod profile
+func testCommand(t *testing.T, loginErr error) (*cmd, *cli.MockUi, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("AWS_SSO_CACHE_PATH", filepath.Join(dir, "cache"))
+
+	awsConfigFile := filepath.Join(dir, "aws-config")
+	require.NoError(t, os.WriteFile(awsConfigFile, []byte(`[profile prod]
+sso_start_url = https://test.awsapps.com/start
+sso_region = us-west-2
+sso_account_id = 123456789012
+sso_role_name = Admin
+`), 0600))
+	appConfigFile := filepath.Join(dir, "app-config.toml")
+	require.NoError(t, os.WriteFile(appConfigFile, []byte(`[aws]
+config_file = "`+awsConfigFile+`"
+`), 0600))
+
+	mockClient := new(MockRoleCredentialsClient)
+	mockClient.On("GetRoleCredentials", mock.Anything, mock.MatchedBy(func(input *sso.GetRoleCredentialsInput) bool {
+		return aws.ToString(input.AccessToken) == "access-token" && aws.ToString(input.AccountId) == "123456789012"
+	})).Return(&sso.GetRoleCredentialsOutput{
+		RoleCredentials: &types.RoleCredentials{
+			AccessKeyId:     aws.String("ASIAEXAMPLE"),
+			SecretAccessKey: aws.String("secret"),
+			SessionToken:    aws.String("session"),
+			Expiration:      time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC).UnixMilli(),
+		},
+	}, nil)
+
+	ui := cli.NewMockUi()
+	c := NewWithDependencies(ui,
+		func(cfg aws.Config) awsprovider.RoleCredentialsClient {
+			assert.Equal(t, "us-west-2", cfg.Region)
+			return mockClient
+		},
+		func(ui cli.Ui, _ aws.Config) awsprovider.Authenticator {
+			return &MockAuthenticator{ui: ui, token: "access-token", err: loginErr}
+		},
+		func() (aws.Config, error) { return aws.Config{}, nil },
+	)
+	return c, ui, appConfigFile
+}
+
+func TestInit(t *testing.T) {
+	c := New(cli.NewMockUi())
+
+	assert.NotNil(t, c.flags)
+	assert.NotEmpty(t, c.help)
+	assert.Equal(t, synopsis, c.Synopsis())
+	assert.Contains(t, c.Help(), "Usage: aws-sso-config credential-process")
+}
+
+func TestRun(t *testing.T) {
+	c, ui, configFile := testCommand(t, nil)
+
+	// The SDKs pass the profile with two dashes
+	assert.Equal(t, 0, c.Run([]string{"-config=" + configFile, "--profile", "prod"}))
+
+	var output map[string]interface{}
+	require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &output))
+	assert.Equal(t, map[string]interface{}{
+		"Version":         float64(1),
+		"AccessKeyId":     "ASIAEXAMPLE",
+		"SecretAccessKey": "secret",
+		"SessionToken":    "session",
+		"Expiration":      "2030-01-02T03:04:05Z",
+	}, output)
+
+	// Login messages do not corrupt the JSON
+	assert.Contains(t, ui.ErrorWriter.String(), "Waiting for authorization...")
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		loginErr error
+		expected string
+	}{
+		{"no profile", nil, nil, "Usage: aws-sso-config credential-process -profile=<name>"},
+		{"missing profile", []string{"-profile=missing"}, nil, `profile "missing" not found`},
+		{"login failure", []string{"-profile=prod"}, awsprovider.ErrAuthorizationDenied, "Error getting credentials for profile prod: authorization was denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ui, configFile := testCommand(t, tt.loginErr)
+			assert.Equal(t, 1, c.Run(append([]string{"-config=" + configFile}, tt.args...)))
+			assert.Contains(t, ui.ErrorWriter.String(), tt.expected)
+			assert.Empty(t, ui.OutputWriter.String())
+		})
+	}
+}
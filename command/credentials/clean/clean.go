@@ -0,0 +1,71 @@
+package clean
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/blairham/aws-sso-config/command/flags"
+	awsprovider "github.com/blairham/aws-sso-config/providers/aws"
+)
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	help  string
+
+	// Dependencies for testing
+	now func() time.Time
+}
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui, now: time.Now}
+	c.Init()
+	return c
+}
+
+func (c *cmd) Init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+	if c.flags.NArg() != 0 {
+		c.UI.Error("Usage: aws-sso-config credentials clean")
+		return 1
+	}
+
+	credentialsFile, err := awsprovider.LoadSharedCredentialsFile()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	removed := credentialsFile.RemoveExpired(c.now())
+	if len(removed) == 0 {
+		c.UI.Output("No expired credentials to remove")
+		return 0
+	}
+	if err := credentialsFile.Save(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	for _, profile := range removed {
+		c.UI.Output(fmt.Sprintf("Removed expired credentials for %s", profile))
+	}
+	return 0
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
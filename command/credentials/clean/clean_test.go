@@ -0,0 +1,71 @@
+package clean
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInit(t *testing.T) {
+	c := New(cli.NewMockUi())
+
+	assert.NotNil(t, c.flags)
+	assert.NotEmpty(t, c.help)
+	assert.Equal(t, synopsis, c.Synopsis())
+	assert.Contains(t, c.Help(), "Usage: aws-sso-config credentials clean")
+}
+
+func TestRun(t *testing.T) {
+	credentialsFile := filepath.Join(t.TempDir(), "credentials")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+	require.NoError(t, os.WriteFile(credentialsFile, []byte(`[manual]
+aws_access_key_id = AKIAMANUAL
+
+# Written by aws-sso-config, expires at 2020-01-01T00:00:00Z
+[expired]
+aws_access_key_id = ASIAEXPIRED
+expiration = 2020-01-01T00:00:00Z
+generated_by = aws-sso-config
+
+# Written by aws-sso-config, expires at 2030-01-01T00:00:00Z
+[valid]
+aws_access_key_id = ASIAVALID
+expiration = 2030-01-01T00:00:00Z
+generated_by = aws-sso-config
+`), 0600))
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	c.now = func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	assert.Equal(t, 0, c.Run(nil))
+	assert.Equal(t, "Removed expired credentials for expired\n", ui.OutputWriter.String())
+
+	data, err := os.ReadFile(credentialsFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[manual]")
+	assert.Contains(t, string(data), "# Written by aws-sso-config, expires at 2030-01-01T00:00:00Z\n[valid]")
+	assert.NotContains(t, string(data), "expired")
+
+	// Nothing is left to remove
+	ui = cli.NewMockUi()
+	c = New(ui)
+	c.now = func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }
+	assert.Equal(t, 0, c.Run(nil))
+	assert.Equal(t, "No expired credentials to remove\n", ui.OutputWriter.String())
+}
+
+func TestRunMissingFile(t *testing.T) {
+	credentialsFile := filepath.Join(t.TempDir(), "credentials")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+
+	ui := cli.NewMockUi()
+	assert.Equal(t, 0, New(ui).Run(nil))
+	assert.Contains(t, ui.OutputWriter.String(), "No expired credentials to remove")
+	assert.NoFileExists(t, credentialsFile)
+}
@@ -0,0 +1,16 @@
+package clean
+
+const synopsis = "Remove expired credentials written by sync"
+const help = `
+Usage: aws-sso-config credentials clean
+
+  This command removes the sections of the shared credentials file
+  (~/.aws/credentials, or AWS_SHARED_CREDENTIALS_FILE) written by
+  "aws-sso-config credentials sync" whose credentials have expired.
+  Other sections are never removed.
+
+Examples:
+
+  # Remove expired credentials
+  aws-sso-config credentials clean
+`
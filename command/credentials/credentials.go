@@ -0,0 +1,78 @@
+package credentials
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/blairham/aws-sso-config/command/credentials/clean"
+	"github.com/blairham/aws-sso-config/command/credentials/sync"
+)
+
+type cmd struct {
+	UI cli.Ui
+}
+
+func New(ui cli.Ui) *cmd {
+	return &cmd{UI: ui}
+}
+
+func (c *cmd) Run(args []string) int {
+	if len(args) == 0 {
+		c.UI.Error("Usage: aws-sso-config credentials <subcommand>")
+		c.outputSubcommands()
+		return 1
+	}
+
+	subcommand := args[0]
+	subArgs := args[1:]
+
+	switch subcommand {
+	case "sync":
+		syncCmd := sync.New(c.UI)
+		return syncCmd.Run(subArgs)
+	case "clean":
+		cleanCmd := clean.New(c.UI)
+		return cleanCmd.Run(subArgs)
+	default:
+		c.UI.Error(fmt.Sprintf("Unknown subcommand: %s", subcommand))
+		c.outputSubcommands()
+		return 1
+	}
+}
+
+// outputSubcommands lists the subcommands after a usage error
+func (c *cmd) outputSubcommands() {
+	c.UI.Error("")
+	c.UI.Error("Available subcommands:")
+	c.UI.Error("  sync [options] <profile>...   Write role credentials to the credentials file")
+	c.UI.Error("  clean                          Remove expired credentials written by sync")
+}
+
+func (c *cmd) Help() string {
+	return `Usage: aws-sso-config credentials <subcommand>
+
+  Manage static credentials in the shared credentials file
+  (~/.aws/credentials, or AWS_SHARED_CREDENTIALS_FILE), for tools
+  which only read credentials from there.
+
+Subcommands:
+  sync [options] <profile>...   Write role credentials for generated
+                                profiles to the credentials file
+  clean                         Remove expired credentials written by sync
+
+Examples:
+  # Write credentials for the prod profile
+  aws-sso-config credentials sync prod
+
+  # Write credentials for every generated profile starting with prod-
+  aws-sso-config credentials sync 'prod-*'
+
+  # Remove expired credentials
+  aws-sso-config credentials clean
+`
+}
+
+func (c *cmd) Synopsis() string {
+	return "Manage credentials in the shared credentials file"
+}
@@ -0,0 +1,52 @@
+package credentials
+
+import (
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentials(t *testing.T) {
+	c := New(cli.NewMockUi())
+
+	assert.NotNil(t, c)
+	assert.Equal(t, "Manage credentials in the shared credentials file", c.Synopsis())
+	assert.Contains(t, c.Help(), "Usage: aws-sso-config credentials <subcommand>")
+}
+
+func TestCredentialsNoArgs(t *testing.T) {
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	assert.Equal(t, 1, c.Run([]string{}))
+
+	errorOutput := ui.ErrorWriter.String()
+	assert.Contains(t, errorOutput, "Usage: aws-sso-config credentials <subcommand>")
+	assert.Contains(t, errorOutput, "sync [options] <profile>...")
+	assert.Contains(t, errorOutput, "clean")
+}
+
+func TestCredentialsInvalidSubcommand(t *testing.T) {
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	assert.Equal(t, 1, c.Run([]string{"invalid"}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Unknown subcommand: invalid")
+}
+
+func TestCredentialsSyncInvalidArgs(t *testing.T) {
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	assert.Equal(t, 1, c.Run([]string{"sync"}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Usage: aws-sso-config credentials sync")
+}
+
+func TestCredentialsCleanInvalidArgs(t *testing.T) {
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	assert.Equal(t, 1, c.Run([]string{"clean", "prod"}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Usage: aws-sso-config credentials clean")
+}
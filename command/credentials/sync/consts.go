@@ -0,0 +1,36 @@
+package sync
+
+const synopsis = "Write role credentials to the shared credentials file"
+const help = `
+Usage: aws-sso-config credentials sync [options] <profile>...
+
+  This command writes temporary credentials for the roles of profiles
+  generated in the AWS config file into the shared credentials file
+  (~/.aws/credentials, or AWS_SHARED_CREDENTIALS_FILE), for tools which
+  only read credentials from there. Profiles are selected by name or by
+  a pattern such as 'prod-*', or all generated profiles with -all.
+
+  Each profile is written to a section of the same name, marked with
+  generated_by = aws-sso-config and a comment saying when its
+  credentials expire. Sections written by hand are never replaced.
+  Run this command again to replace expired credentials, and
+  "aws-sso-config credentials clean" to remove them.
+
+  You are asked to log in to AWS SSO when there is no valid cached
+  token.
+
+Options:
+
+  -all              Write credentials for every generated profile.
+
+  -config=<path>    Path to configuration file. If not specified,
+                    uses environment variables and defaults.
+
+Examples:
+
+  # Write credentials for the prod and staging profiles
+  aws-sso-config credentials sync prod staging
+
+  # Write credentials for every generated profile starting with prod-
+  aws-sso-config credentials sync 'prod-*'
+`
@@ -0,0 +1,143 @@
+package sync
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/mitchellh/cli"
+
+	"github.com/blairham/aws-sso-config/command/flags"
+	"github.com/blairham/aws-sso-config/command/internal/rolecreds"
+	awsprovider "github.com/blairham/aws-sso-config/providers/aws"
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	help  string
+
+	all        bool
+	configFile string
+
+	// Dependencies for testing
+	credentials rolecreds.Source
+}
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui, credentials: rolecreds.New()}
+	c.Init()
+	return c
+}
+
+// NewWithDependencies creates a new command with injected dependencies for testing
+func NewWithDependencies(
+	ui cli.Ui,
+	ssoClientFactory func(aws.Config) awsprovider.RoleCredentialsClient,
+	newAuthenticator func(ui cli.Ui, cfg aws.Config) awsprovider.Authenticator,
+	configLoader func() (aws.Config, error),
+) *cmd {
+	c := &cmd{UI: ui, credentials: rolecreds.Source{
+		SSOClientFactory: ssoClientFactory,
+		NewAuthenticator: newAuthenticator,
+		ConfigLoader:     configLoader,
+	}}
+	c.Init()
+	return c
+}
+
+func (c *cmd) Init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.BoolVar(&c.all, "all", false, "Write credentials for every generated profile.")
+	c.flags.StringVar(&c.configFile, "config", "", "Path to configuration file.")
+
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+	patterns := c.flags.Args()
+	if c.all {
+		patterns = []string{"*"}
+	}
+	if len(patterns) == 0 {
+		c.UI.Error("Usage: aws-sso-config credentials sync [-all] <profile>...")
+		return 1
+	}
+
+	appCfg, err := appconfig.LoadOrDefault(c.configFile)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Configuration error: %v", err))
+		return 1
+	}
+
+	profiles, err := awsprovider.GeneratedProfiles(appCfg.ConfigFile(), patterns)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if len(profiles) == 0 {
+		c.UI.Error(fmt.Sprintf("No generated profiles in %s match %v", appCfg.ConfigFile(), patterns))
+		return 1
+	}
+
+	credentialsFile, err := awsprovider.LoadSharedCredentialsFile()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	written, failed := 0, false
+	for _, name := range profiles {
+		if err := c.syncProfile(credentialsFile, appCfg, name); err != nil {
+			c.UI.Error(fmt.Sprintf("Error writing credentials for profile %s: %v", name, err))
+			failed = true
+			// Ctrl-C stops the remaining profiles too, keeping those already written
+			if errors.Is(err, awsprovider.ErrLoginCanceled) {
+				break
+			}
+			continue
+		}
+		written++
+	}
+
+	if written > 0 {
+		if err := credentialsFile.Save(); err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+		c.UI.Output(fmt.Sprintf("Wrote credentials for %d profiles to %s", written, credentialsFile.Path()))
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// syncProfile gets credentials for the role of the profile and sets them in the
+// credentials file
+func (c *cmd) syncProfile(credentialsFile *awsprovider.CredentialsFile, appCfg *appconfig.Config, name string) error {
+	_, creds, err := c.credentials.Get(c.UI, appCfg, name)
+	if err != nil {
+		return err
+	}
+	if err := credentialsFile.SetCredentials(name, creds); err != nil {
+		return err
+	}
+
+	c.UI.Info(fmt.Sprintf("%s: credentials expire at %s", name, creds.Expiration.Local().Format(time.RFC1123)))
+	return nil
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
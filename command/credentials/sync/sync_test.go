@@ -0,0 +1,216 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/bigkevmcd/go-configparser"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	awsprovider "github.com/blairham/aws-sso-config/providers/aws"
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// Mock implementation of RoleCredentialsClient interface for testing
+type MockRoleCredentialsClient struct {
+	mock.Mock
+}
+
+func (m *MockRoleCredentialsClient) GetRoleCredentials(
+	ctx context.Context,
+	params *sso.GetRoleCredentialsInput,
+	optFns ...func(*sso.Options),
+) (*sso.GetRoleCredentialsOutput, error) {
+	args := m.Called(ctx, params)
+	result := args.Get(0)
+	if result == nil {
+		return nil, args.Error(1)
+	}
+	return result.(*sso.GetRoleCredentialsOutput), args.Error(1)
+}
+
+// MockAuthenticator returns a fixed token or error
+type MockAuthenticator struct {
+	token string
+	err   error
+}
+
+func (m *MockAuthenticator) Authenticate(context.Context, *appconfig.Config) (*string, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &m.token, nil
+}
+
+// roleCredentials returns the credentials the mock client returns for the account
+func roleCredentials(accountID string) *sso.GetRoleCredentialsOutput {
+	return &sso.GetRoleCredentialsOutput{
+		RoleCredentials: &types.RoleCredentials{
+			AccessKeyId:     aws.String("ASIA" + accountID),
+			SecretAccessKey: aws.String("secret"),
+			SessionToken:    aws.String("session"),
+			Expiration:      time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC).UnixMilli(),
+		},
+	}
+}
+
+// testCommand returns a command using an AWS config file with generated prod and
+// staging profiles and a hand-written manual profile, and the credentials file
+func testCommand(t *testing.T, loginErr error) (*cmd, *cli.MockUi, string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("AWS_SSO_CACHE_PATH", filepath.Join(dir, "cache"))
+	credentialsFile := filepath.Join(dir, "credentials")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+
+	awsConfigFile := filepath.Join(dir, "aws-config")
+	require.NoError(t, os.WriteFile(awsConfigFile, []byte(`[profile prod]
+sso_start_url = https://test.awsapps.com/start
+sso_region = us-west-2
+sso_account_id = 111111111111
+sso_role_name = Admin
+generated_by = aws-sso-config
+
+[profile staging]
+sso_start_url = https://test.awsapps.com/start
+sso_region = us-west-2
+sso_account_id = 222222222222
+sso_role_name = Admin
+generated_by = aws-sso-config
+
+[profile manual]
+sso_start_url = https://test.awsapps.com/start
+sso_region = us-west-2
+sso_account_id = 333333333333
+sso_role_name = Admin
+`), 0600))
+	appConfigFile := filepath.Join(dir, "app-config.toml")
+	require.NoError(t, os.WriteFile(appConfigFile, []byte(`[aws]
+config_file = "`+awsConfigFile+`"
+`), 0600))
+
+	mockClient := new(MockRoleCredentialsClient)
+	for _, accountID := range []string{"111111111111", "222222222222"} {
+		mockClient.On("GetRoleCredentials", mock.Anything, mock.MatchedBy(func(input *sso.GetRoleCredentialsInput) bool {
+			return aws.ToString(input.AccessToken) == "access-token" && aws.ToString(input.AccountId) == accountID
+		})).Return(roleCredentials(accountID), nil)
+	}
+
+	ui := cli.NewMockUi()
+	c := NewWithDependencies(ui,
+		func(cfg aws.Config) awsprovider.RoleCredentialsClient {
+			assert.Equal(t, "us-west-2", cfg.Region)
+			return mockClient
+		},
+		func(cli.Ui, aws.Config) awsprovider.Authenticator {
+			return &MockAuthenticator{token: "access-token", err: loginErr}
+		},
+		func() (aws.Config, error) { return aws.Config{}, nil },
+	)
+	return c, ui, appConfigFile, credentialsFile
+}
+
+func TestInit(t *testing.T) {
+	c := New(cli.NewMockUi())
+
+	assert.NotNil(t, c.flags)
+	assert.NotEmpty(t, c.help)
+	assert.Equal(t, synopsis, c.Synopsis())
+	assert.Contains(t, c.Help(), "Usage: aws-sso-config credentials sync")
+}
+
+func TestRun(t *testing.T) {
+	c, ui, configFile, credentialsFile := testCommand(t, nil)
+
+	assert.Equal(t, 0, c.Run([]string{"-config=" + configFile, "-all"}))
+	assert.Contains(t, ui.OutputWriter.String(), "Wrote credentials for 2 profiles to "+credentialsFile)
+
+	credentials, err := configparser.NewConfigParserFromFile(credentialsFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod", "staging"}, credentials.Sections())
+
+	items, err := credentials.Items("prod")
+	require.NoError(t, err)
+	assert.Equal(t, "ASIA111111111111", items["aws_access_key_id"])
+	assert.Equal(t, "secret", items["aws_secret_access_key"])
+	assert.Equal(t, "session", items["aws_session_token"])
+	assert.Equal(t, "2030-01-02T03:04:05Z", items["expiration"])
+	assert.Equal(t, "aws-sso-config", items["generated_by"])
+
+	data, err := os.ReadFile(credentialsFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Written by aws-sso-config, expires at 2030-01-02T03:04:05Z\n[prod]\n")
+}
+
+func TestRunSelectedProfiles(t *testing.T) {
+	c, _, configFile, credentialsFile := testCommand(t, nil)
+	// Sections written by hand are kept
+	require.NoError(t, os.WriteFile(credentialsFile, []byte("[manual]\naws_access_key_id = AKIAMANUAL\n"), 0600))
+
+	assert.Equal(t, 0, c.Run([]string{"-config=" + configFile, "stag*"}))
+
+	credentials, err := configparser.NewConfigParserFromFile(credentialsFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"manual", "staging"}, credentials.Sections())
+	value, err := credentials.Get("manual", "aws_access_key_id")
+	require.NoError(t, err)
+	assert.Equal(t, "AKIAMANUAL", value)
+}
+
+func TestRunHandWrittenSection(t *testing.T) {
+	c, ui, configFile, credentialsFile := testCommand(t, nil)
+	require.NoError(t, os.WriteFile(credentialsFile, []byte("[prod]\naws_access_key_id = AKIAMANUAL\n"), 0600))
+
+	// The other profiles are still written
+	assert.Equal(t, 1, c.Run([]string{"-config=" + configFile, "prod", "staging"}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Error writing credentials for profile prod: profile prod in "+credentialsFile+" was not written by aws-sso-config")
+
+	credentials, err := configparser.NewConfigParserFromFile(credentialsFile)
+	require.NoError(t, err)
+	value, err := credentials.Get("prod", "aws_access_key_id")
+	require.NoError(t, err)
+	assert.Equal(t, "AKIAMANUAL", value)
+	assert.True(t, credentials.HasSection("staging"))
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		loginErr error
+		expected string
+	}{
+		{"no profiles", nil, nil, "Usage: aws-sso-config credentials sync [-all] <profile>..."},
+		{"no match", []string{"dev-*"}, nil, "No generated profiles in"},
+		{"not generated", []string{"manual"}, nil, "No generated profiles in"},
+		{"invalid pattern", []string{"[prod"}, nil, `invalid profile pattern "[prod"`},
+		{"login failure", []string{"prod"}, awsprovider.ErrAuthorizationDenied, "Error writing credentials for profile prod: authorization was denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ui, configFile, credentialsFile := testCommand(t, tt.loginErr)
+			assert.Equal(t, 1, c.Run(append([]string{"-config=" + configFile}, tt.args...)))
+			assert.Contains(t, ui.ErrorWriter.String(), tt.expected)
+			assert.NoFileExists(t, credentialsFile)
+		})
+	}
+}
+
+func TestRunLoginCanceled(t *testing.T) {
+	c, ui, configFile, _ := testCommand(t, awsprovider.ErrLoginCanceled)
+
+	// The remaining profiles are not tried after Ctrl-C
+	assert.Equal(t, 1, c.Run([]string{"-config=" + configFile, "-all"}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Error writing credentials for profile prod: login canceled")
+	assert.NotContains(t, ui.ErrorWriter.String(), "profile staging")
+}
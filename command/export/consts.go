@@ -0,0 +1,43 @@
+package export
+
+const synopsis = "Print role credentials as environment variables"
+const help = `
+Usage: aws-sso-config export -profile=<name> [options]
+
+  This command prints temporary credentials for the role of an SSO
+  profile of the AWS config file as environment variable assignments
+  for a shell to evaluate, or for scripts and CI to read:
+
+    AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN
+    AWS_CREDENTIAL_EXPIRATION   When the credentials expire
+    AWS_REGION, AWS_DEFAULT_REGION
+
+  The cached SSO token is used, and refreshed when it has expired. You
+  are only asked to log in when there is no token to refresh; login
+  messages are written to stderr.
+
+Options:
+
+  -profile=<name>   Profile of the AWS config file to get credentials for.
+                    Required.
+
+  -format=<format>  Output format: bash (also for sh and zsh), fish,
+                    powershell, dotenv or json. Defaults to bash.
+
+  -config=<path>    Path to configuration file. If not specified,
+                    uses environment variables and defaults.
+
+Examples:
+
+  # Set the credentials of the prod profile in bash or zsh
+  eval "$(aws-sso-config export -profile=prod)"
+
+  # In fish
+  aws-sso-config export -profile=prod -format=fish | source
+
+  # In PowerShell
+  aws-sso-config export -profile=prod -format=powershell | Invoke-Expression
+
+  # Write a .env file
+  aws-sso-config export -profile=prod -format=dotenv > .env
+`
@@ -0,0 +1,180 @@
+package export
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/mitchellh/cli"
+
+	commandcli "github.com/blairham/aws-sso-config/command/cli"
+	"github.com/blairham/aws-sso-config/command/flags"
+	"github.com/blairham/aws-sso-config/command/internal/rolecreds"
+	awsprovider "github.com/blairham/aws-sso-config/providers/aws"
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// Output formats
+const (
+	formatBash       = "bash"
+	formatFish       = "fish"
+	formatPowerShell = "powershell"
+	formatDotenv     = "dotenv"
+	formatJSON       = "json"
+)
+
+// formats are the supported output formats
+var formats = []string{formatBash, formatFish, formatPowerShell, formatDotenv, formatJSON}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	help  string
+
+	profile    string
+	format     string
+	configFile string
+
+	// Dependencies for testing
+	credentials rolecreds.Source
+}
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui, credentials: rolecreds.New()}
+	c.Init()
+	return c
+}
+
+// NewWithDependencies creates a new command with injected dependencies for testing
+func NewWithDependencies(
+	ui cli.Ui,
+	ssoClientFactory func(aws.Config) awsprovider.RoleCredentialsClient,
+	newAuthenticator func(ui cli.Ui, cfg aws.Config) awsprovider.Authenticator,
+	configLoader func() (aws.Config, error),
+) *cmd {
+	c := &cmd{UI: ui, credentials: rolecreds.Source{
+		SSOClientFactory: ssoClientFactory,
+		NewAuthenticator: newAuthenticator,
+		ConfigLoader:     configLoader,
+	}}
+	c.Init()
+	return c
+}
+
+func (c *cmd) Init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.StringVar(&c.profile, "profile", "", "Profile of the AWS config file to get credentials for.")
+	c.flags.StringVar(&c.format, "format", formatBash, "Output format: "+strings.Join(formats, ", ")+".")
+	c.flags.StringVar(&c.configFile, "config", "", "Path to configuration file.")
+
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+	if c.profile == "" {
+		c.UI.Error("Usage: aws-sso-config export -profile=<name> [-format=<format>]")
+		return 1
+	}
+	// The format is checked before logging in, which may need the user
+	if _, err := formatExports(c.format, nil); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	appCfg, err := appconfig.LoadOrDefault(c.configFile)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Configuration error: %v", err))
+		return 1
+	}
+
+	// Stdout is evaluated by the shell, so login progress goes to stderr
+	profile, creds, err := c.credentials.Get(&commandcli.StderrUI{Ui: c.UI}, appCfg, c.profile)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error getting credentials for profile %s: %v", c.profile, err))
+		return 1
+	}
+
+	output, err := formatExports(c.format, creds.Environment(profile.Region))
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	c.UI.Output(output)
+	return 0
+}
+
+// formatExports returns the assignments of the environment variables in the format
+func formatExports(format string, vars []awsprovider.EnvVar) (string, error) {
+	var quote func(string) string
+	var line string
+	switch format {
+	case formatBash:
+		quote, line = posixQuote, "export %s=%s"
+	case formatFish:
+		quote, line = fishQuote, "set -gx %s %s"
+	case formatPowerShell:
+		quote, line = powerShellQuote, "$Env:%s = %s"
+	case formatDotenv:
+		quote, line = dotenvQuote, "%s=%s"
+	case formatJSON:
+		values := make(map[string]string, len(vars))
+		for _, v := range vars {
+			values[v.Name] = v.Value
+		}
+		output, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("error encoding credentials: %w", err)
+		}
+		return string(output), nil
+	default:
+		return "", fmt.Errorf("invalid format %q: must be one of %s", format, strings.Join(formats, ", "))
+	}
+
+	lines := make([]string, 0, len(vars))
+	for _, v := range vars {
+		lines = append(lines, fmt.Sprintf(line, v.Name, quote(v.Value)))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// posixQuote single-quotes the value for sh, bash and zsh
+func posixQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes the value for fish, which escapes backslashes and
+// quotes inside single quotes
+func fishQuote(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value) + "'"
+}
+
+// powerShellQuote single-quotes the value for PowerShell, which doubles quotes
+// inside single quotes
+func powerShellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// dotenvQuote leaves plain values unquoted and double-quotes any other value
+func dotenvQuote(value string) string {
+	plain := value != "" && strings.IndexFunc(value, func(r rune) bool {
+		return !strings.ContainsRune("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+-/=.:_", r)
+	}) < 0
+	if plain {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`).Replace(value) + `"`
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
@@ -0,0 +1,19 @@
+package flags
+
+import "strings"
+
+// StringSliceValue is a flag.Value which collects every occurrence of a
+// repeatable flag.
+type StringSliceValue []string
+
+func (s *StringSliceValue) String() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(*s, ",")
+}
+
+func (s *StringSliceValue) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
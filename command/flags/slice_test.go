@@ -0,0 +1,24 @@
+package flags
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringSliceValue(t *testing.T) {
+	var values StringSliceValue
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&values, "include", "Include rule")
+
+	err := fs.Parse([]string{"-include", "prod-*", "-include=role:ReadOnly"})
+	require.NoError(t, err)
+
+	assert.Equal(t, StringSliceValue{"prod-*", "role:ReadOnly"}, values)
+	assert.Equal(t, "prod-*,role:ReadOnly", values.String())
+
+	var empty *StringSliceValue
+	assert.Equal(t, "", empty.String())
+}
@@ -2,11 +2,14 @@ package generate
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/service/sso"
 	"github.com/aws/aws-sdk-go-v2/service/sso/types"
 	"github.com/bigkevmcd/go-configparser"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
@@ -45,17 +48,38 @@ func TestShowFileDiff(t *testing.T) {
 	file2Path := tempDir + "/file2"
 
 	// Create test files
-	err := os.WriteFile(file1Path, []byte("test content 1"), 0600)
+	err := os.WriteFile(file1Path, []byte("[profile prod]\nregion = us-east-1\n"), 0600)
 	require.NoError(t, err)
-	err = os.WriteFile(file2Path, []byte("test content 2"), 0600)
+	err = os.WriteFile(file2Path, []byte("[profile prod]\nregion = eu-west-1\n"), 0600)
 	require.NoError(t, err)
 
-	// Call the function (it just prints to stdout, so we're mostly testing it doesn't crash)
-	showFileDiff(file1Path, file2Path)
+	ui := cli.NewMockUi()
+	showFileDiff(ui, file1Path, file2Path, false)
+	assert.Equal(t, strings.Join([]string{
+		"--- " + file1Path,
+		"+++ " + file2Path,
+		"@@ -1,2 +1,2 @@",
+		" [profile prod]",
+		"-region = us-east-1",
+		"+region = eu-west-1",
+	}, "\n")+"\n", ui.OutputWriter.String())
+
+	// Colorized output
+	ui = cli.NewMockUi()
+	showFileDiff(ui, file1Path, file2Path, true)
+	assert.Contains(t, ui.OutputWriter.String(), colorRed+"-region = us-east-1"+colorReset)
+	assert.Contains(t, ui.OutputWriter.String(), colorGreen+"+region = eu-west-1"+colorReset)
+
+	// Identical files
+	ui = cli.NewMockUi()
+	showFileDiff(ui, file1Path, file1Path, false)
+	assert.Equal(t, "No changes\n", ui.OutputWriter.String())
 
 	// Test with nonexistent file
-	showFileDiff(file1Path, tempDir+"/nonexistent")
-	showFileDiff(tempDir+"/nonexistent", file2Path)
+	ui = cli.NewMockUi()
+	showFileDiff(ui, file1Path, tempDir+"/nonexistent", false)
+	assert.Contains(t, ui.ErrorWriter.String(), "Unable to read")
+	assert.Empty(t, ui.OutputWriter.String())
 }
 
 // TestMockConfigGeneration tests the mock configuration
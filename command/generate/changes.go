@@ -0,0 +1,123 @@
+package generate
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/bigkevmcd/go-configparser"
+	"github.com/mitchellh/cli"
+)
+
+// exitChangesPending is returned by a dry run when the config file would be changed
+const exitChangesPending = 2
+
+// configChanges lists the sections added, updated and removed by a generate run
+type configChanges struct {
+	Added   []string
+	Updated []string
+	Removed []string
+
+	// Keys describes the changed keys of each updated section
+	Keys map[string][]string
+}
+
+// Count returns the total number of changed sections
+func (c configChanges) Count() int {
+	return len(c.Added) + len(c.Updated) + len(c.Removed)
+}
+
+// snapshotSections returns a copy of the options of every section
+func snapshotSections(configParser *configparser.ConfigParser) map[string]map[string]string {
+	snapshot := make(map[string]map[string]string)
+	for _, section := range configParser.Sections() {
+		items, err := configParser.Items(section)
+		if err != nil {
+			continue
+		}
+		snapshot[section] = maps.Clone(map[string]string(items))
+	}
+	return snapshot
+}
+
+// compareSections returns the sections which differ between two snapshots, in
+// the order they appear in the config
+func compareSections(configParser *configparser.ConfigParser, before map[string]map[string]string) configChanges {
+	var changes configChanges
+	after := snapshotSections(configParser)
+
+	for _, section := range configParser.Sections() {
+		previous, existed := before[section]
+		switch {
+		case !existed:
+			changes.Added = append(changes.Added, section)
+		case !maps.Equal(previous, after[section]):
+			changes.Updated = append(changes.Updated, section)
+			if changes.Keys == nil {
+				changes.Keys = make(map[string][]string)
+			}
+			changes.Keys[section] = compareKeys(previous, after[section])
+		}
+	}
+	for section := range before {
+		if _, exists := after[section]; !exists {
+			changes.Removed = append(changes.Removed, section)
+		}
+	}
+	slices.Sort(changes.Removed)
+
+	return changes
+}
+
+// compareKeys describes the keys added, changed and removed in a section
+func compareKeys(before, after map[string]string) []string {
+	var keys []string
+	for _, key := range slices.Sorted(maps.Keys(after)) {
+		previous, existed := before[key]
+		switch {
+		case !existed:
+			keys = append(keys, fmt.Sprintf("+ %s = %s", key, after[key]))
+		case previous != after[key]:
+			keys = append(keys, fmt.Sprintf("~ %s = %s (was %s)", key, after[key], previous))
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(before)) {
+		if _, exists := after[key]; !exists {
+			keys = append(keys, fmt.Sprintf("- %s = %s", key, before[key]))
+		}
+	}
+	return keys
+}
+
+// reportChanges writes the changed sections to the UI. A dry run describes what
+// would change along with a summary line.
+func reportChanges(ui cli.Ui, changes configChanges, dryRun bool) {
+	verbs := [3]string{"Adding", "Updating", "Removing"}
+	if dryRun {
+		verbs = [3]string{"Would add", "Would update", "Would remove"}
+	}
+
+	for i, sections := range [3][]string{changes.Added, changes.Updated, changes.Removed} {
+		for _, section := range sections {
+			ui.Output(fmt.Sprintf("%s %s", verbs[i], section))
+			for _, key := range changes.Keys[section] {
+				ui.Output("    " + key)
+			}
+		}
+	}
+
+	if !dryRun {
+		return
+	}
+	if changes.Count() == 0 {
+		ui.Output("Dry run: no changes")
+		return
+	}
+	summary := []string{
+		fmt.Sprintf("%d added", len(changes.Added)),
+		fmt.Sprintf("%d updated", len(changes.Updated)),
+		fmt.Sprintf("%d removed", len(changes.Removed)),
+	}
+	ui.Output(fmt.Sprintf("Dry run: %s", strings.Join(summary, ", ")))
+}
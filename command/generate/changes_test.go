@@ -0,0 +1,77 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bigkevmcd/go-configparser"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareSections(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(configFile, []byte(`[profile same]
+region = us-east-1
+
+[profile changed]
+region = us-east-1
+
+[profile removed]
+region = us-east-1
+`), 0600)
+	require.NoError(t, err)
+
+	configParser, err := configparser.NewConfigParserFromFile(configFile)
+	require.NoError(t, err)
+	before := snapshotSections(configParser)
+
+	require.NoError(t, configParser.Set("profile changed", "region", "eu-west-1"))
+	require.NoError(t, configParser.RemoveSection("profile removed"))
+	require.NoError(t, configParser.AddSection("profile added"))
+
+	changes := compareSections(configParser, before)
+	assert.Equal(t, []string{"profile added"}, changes.Added)
+	assert.Equal(t, []string{"profile changed"}, changes.Updated)
+	assert.Equal(t, []string{"~ region = eu-west-1 (was us-east-1)"}, changes.Keys["profile changed"])
+	assert.Equal(t, []string{"profile removed"}, changes.Removed)
+	assert.Equal(t, 3, changes.Count())
+}
+
+func TestCompareKeys(t *testing.T) {
+	keys := compareKeys(
+		map[string]string{"region": "eu-west-1", "sso_start_url": "https://test", "output": "json"},
+		map[string]string{"region": "us-east-1", "sso_session": "my-org", "output": "json"},
+	)
+	assert.Equal(t, []string{
+		"~ region = us-east-1 (was eu-west-1)",
+		"+ sso_session = my-org",
+		"- sso_start_url = https://test",
+	}, keys)
+}
+
+func TestReportChanges(t *testing.T) {
+	changes := configChanges{
+		Added:   []string{"profile prod"},
+		Updated: []string{"profile dev"},
+		Removed: []string{"profile old"},
+		Keys:    map[string][]string{"profile dev": {"~ region = us-east-1 (was eu-west-1)"}},
+	}
+
+	ui := cli.NewMockUi()
+	reportChanges(ui, changes, false)
+	assert.Equal(t, "Adding profile prod\nUpdating profile dev\n    ~ region = us-east-1 (was eu-west-1)\n"+
+		"Removing profile old\n", ui.OutputWriter.String())
+
+	ui = cli.NewMockUi()
+	reportChanges(ui, changes, true)
+	assert.Equal(t, "Would add profile prod\nWould update profile dev\n    ~ region = us-east-1 (was eu-west-1)\n"+
+		"Would remove profile old\n"+
+		"Dry run: 1 added, 1 updated, 1 removed\n", ui.OutputWriter.String())
+
+	ui = cli.NewMockUi()
+	reportChanges(ui, configChanges{}, true)
+	assert.Equal(t, "Dry run: no changes\n", ui.OutputWriter.String())
+}
@@ -7,10 +7,61 @@ Usage: aws-sso-config generate [options]
   This command will auto-generate an AWS config file
   with all accounts you have access to.
 
+  By default one profile is generated per account using the
+  configured SSO role. With -all-roles (or generate.all_roles)
+  the roles of each account are listed and one profile is
+  generated for every account and role pair.
+
 Options:
 
   -diff             Enable diff output to see changes before writing.
 
+  -color            Colorize the diff output.
+
+  -dry-run          Show the profiles that would be added, updated or
+                    removed (and the diff with -diff) without writing
+                    the AWS config file. Exits with status 2 when there
+                    are changes to apply and 0 when there are none.
+
+  -confirm          List the changes and ask "Apply these N changes?"
+                    before writing the AWS config file. Also enabled
+                    by generate.confirm.
+
+  -yes              Apply changes without asking for confirmation,
+                    e.g. in scripts when generate.confirm is set.
+
+  -all-roles        Generate a profile for every role you can assume
+                    in every account, named <account>-<role>.
+
+  -include=<rule>   Only generate profiles matching the rule. Rules are
+                    written as [field:]pattern where field is account
+                    (default), id or role and pattern is a glob or a
+                    /regular expression/. Can be repeated; a profile
+                    must match one of the account (name or id) rules
+                    and one of the role rules.
+
+  -exclude=<rule>   Do not generate profiles matching the rule. Can be
+                    repeated. Added to any rules in the config file.
+
+  -prune            Remove profiles written by a previous run that are
+                    no longer generated, e.g. because the account was
+                    closed, access was removed or the profile is now
+                    filtered out. Generated sections are marked with
+                    generated_by = aws-sso-config; sections without
+                    the marker are never removed.
+
+  -no-browser       Print the login URL and code to enter there instead
+                    of opening a browser, e.g. on a remote machine or
+                    in a container. Also enabled by login.headless, and
+                    automatic when no display is available.
+
+  -credential-process
+                    Write credential_process = aws-sso-config
+                    credential-process --profile <name> into every
+                    generated profile, for tools which do not support
+                    SSO profiles. Also enabled by
+                    generate.credential_process.
+
   -config=<path>    Path to configuration file. If not specified,
                     uses environment variables and defaults.
 
@@ -22,6 +73,24 @@ Examples:
   # Generate using a custom config file
   aws-sso-config generate -config=my-config.yaml
 
+  # Generate one profile per account and role
+  aws-sso-config generate -all-roles
+
+  # Skip sandbox accounts and only generate read-only profiles
+  aws-sso-config generate -all-roles -exclude='sandbox-*' -include='role:ReadOnly*'
+
+  # Remove profiles for accounts you no longer have access to
+  aws-sso-config generate -prune
+
+  # Preview changes without writing the AWS config file
+  aws-sso-config generate -dry-run -diff
+
+  # Log in from a machine without a browser
+  aws-sso-config generate -no-browser
+
+  # Let tools without SSO support use the generated profiles
+  aws-sso-config generate -credential-process
+
   # Show diff before writing changes
   aws-sso-config generate -diff -config=my-config.yaml
 `
@@ -0,0 +1,219 @@
+package generate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// noNewlineMarker follows a last line which does not end in a newline, as in diff -u
+const noNewlineMarker = `\ No newline at end of file`
+
+// ANSI escape sequences used to colorize diff output
+const (
+	colorReset = "\x1b[0m"
+	colorBold  = "\x1b[1m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+)
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffLine is a single line of an edit script
+type diffLine struct {
+	kind diffKind
+	text string
+}
+
+// diffLines returns the shortest edit script turning a into b, using Myers'
+// O(ND) algorithm
+func diffLines(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	limit := n + m
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+
+	// trace[d] holds the furthest reaching x for diagonals -d-1..d+1 before step d
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		snapshot := make([]int, 2*d+3)
+		copy(snapshot, v[offset-d-1:offset+d+2])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackDiff(trace, a, b)
+			}
+		}
+	}
+
+	return nil
+}
+
+// backtrackDiff walks the trace from the end of both inputs to recover the edit script
+func backtrackDiff(trace [][]int, a, b []string) []diffLine {
+	var lines []diffLine
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			lines = append(lines, diffLine{diffEqual, a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			lines = append(lines, diffLine{diffInsert, b[y-1]})
+		} else {
+			lines = append(lines, diffLine{diffDelete, a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		lines = append(lines, diffLine{diffEqual, a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
+
+// hunkRange formats the range of a hunk header the way diff -u does
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
+
+// unifiedDiff returns the unified diff of a and b, or nil when they are equal
+func unifiedDiff(fromName, toName string, a, b []string) []string {
+	lines := diffLines(a, b)
+
+	var changes []int
+	for i, line := range lines {
+		if line.kind != diffEqual {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// Line positions in a and b before each edit
+	aPos := make([]int, len(lines)+1)
+	bPos := make([]int, len(lines)+1)
+	for i, line := range lines {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if line.kind != diffInsert {
+			aPos[i+1]++
+		}
+		if line.kind != diffDelete {
+			bPos[i+1]++
+		}
+	}
+
+	output := []string{"--- " + fromName, "+++ " + toName}
+	for i := 0; i < len(changes); {
+		// Changes with at most twice the context between them share a hunk
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*diffContext+1 {
+			j++
+		}
+		start := max(0, changes[i]-diffContext)
+		end := min(len(lines), changes[j]+diffContext+1)
+
+		output = append(output, fmt.Sprintf("@@ -%s +%s @@",
+			hunkRange(aPos[start], aPos[end]-aPos[start]),
+			hunkRange(bPos[start], bPos[end]-bPos[start])))
+		for _, line := range lines[start:end] {
+			prefix := " "
+			switch line.kind {
+			case diffDelete:
+				prefix = "-"
+			case diffInsert:
+				prefix = "+"
+			}
+			text, marker, noNewline := strings.Cut(line.text, "\n")
+			output = append(output, prefix+text)
+			if noNewline {
+				output = append(output, marker)
+			}
+		}
+		i = j + 1
+	}
+
+	return output
+}
+
+// colorizeDiff wraps each line of a unified diff in ANSI colors
+func colorizeDiff(lines []string) []string {
+	colored := make([]string, len(lines))
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+			colored[i] = colorBold + line + colorReset
+		case strings.HasPrefix(line, "@@"):
+			colored[i] = colorCyan + line + colorReset
+		case strings.HasPrefix(line, "-"):
+			colored[i] = colorRed + line + colorReset
+		case strings.HasPrefix(line, "+"):
+			colored[i] = colorGreen + line + colorReset
+		default:
+			colored[i] = line
+		}
+	}
+	return colored
+}
+
+// splitLines splits file content into lines without their line endings. A last
+// line without a newline is followed by the marker, after a newline, so that it
+// differs from the same line with one.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if !strings.HasSuffix(content, "\n") {
+		lines[len(lines)-1] += "\n" + noNewlineMarker
+	}
+	return lines
+}
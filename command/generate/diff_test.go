@@ -0,0 +1,138 @@
+package generate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// applyDiff rebuilds both inputs from an edit script
+func applyDiff(lines []diffLine) ([]string, []string) {
+	a, b := []string{}, []string{}
+	for _, line := range lines {
+		if line.kind != diffInsert {
+			a = append(a, line.text)
+		}
+		if line.kind != diffDelete {
+			b = append(b, line.text)
+		}
+	}
+	return a, b
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		edits int
+	}{
+		{"equal", "a b c", "a b c", 0},
+		{"both empty", "", "", 0},
+		{"insert into empty", "", "a b", 2},
+		{"delete everything", "a b", "", 2},
+		{"replace middle", "a b c", "a x c", 2},
+		{"classic", "a b c a b b a", "c b a b a c", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+			lines := diffLines(a, b)
+
+			gotA, gotB := applyDiff(lines)
+			assert.Equal(t, a, gotA)
+			assert.Equal(t, b, gotB)
+
+			edits := 0
+			for _, line := range lines {
+				if line.kind != diffEqual {
+					edits++
+				}
+			}
+			assert.Equal(t, tt.edits, edits)
+		})
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Run("equal inputs", func(t *testing.T) {
+		assert.Nil(t, unifiedDiff("a", "b", []string{"x"}, []string{"x"}))
+	})
+
+	t.Run("separate hunks", func(t *testing.T) {
+		a := strings.Fields("1 2 3 4 5 6 7 8 9 10 11 12")
+		b := strings.Fields("1 two 3 4 5 6 7 8 9 10 11 12 13")
+
+		assert.Equal(t, []string{
+			"--- old",
+			"+++ new",
+			"@@ -1,5 +1,5 @@",
+			" 1",
+			"-2",
+			"+two",
+			" 3",
+			" 4",
+			" 5",
+			"@@ -10,3 +10,4 @@",
+			" 10",
+			" 11",
+			" 12",
+			"+13",
+		}, unifiedDiff("old", "new", a, b))
+	})
+
+	t.Run("nearby changes share a hunk", func(t *testing.T) {
+		a := strings.Fields("1 2 3 4 5 6 7 8")
+		b := strings.Fields("1 x 3 4 5 6 y 8")
+
+		diff := unifiedDiff("old", "new", a, b)
+		assert.Equal(t, "@@ -1,8 +1,8 @@", diff[2])
+		assert.Len(t, diff, 13)
+	})
+
+	t.Run("six unchanged lines between changes share a hunk", func(t *testing.T) {
+		a := strings.Fields("1 2 3 4 5 6 7 8 9 10 11")
+		b := strings.Fields("1 x 3 4 5 6 7 8 y 10 11")
+
+		diff := unifiedDiff("old", "new", a, b)
+		assert.Equal(t, "@@ -1,11 +1,11 @@", diff[2])
+		assert.Len(t, diff, 16)
+	})
+
+	t.Run("seven unchanged lines between changes split hunks", func(t *testing.T) {
+		a := strings.Fields("1 2 3 4 5 6 7 8 9 10 11")
+		b := strings.Fields("1 x 3 4 5 6 7 8 9 y 11")
+
+		diff := unifiedDiff("old", "new", a, b)
+		assert.Equal(t, "@@ -1,5 +1,5 @@", diff[2])
+		assert.Equal(t, "@@ -7,5 +7,5 @@", diff[9])
+	})
+
+	t.Run("no newline at end of file", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"--- old",
+			"+++ new",
+			"@@ -1,2 +1,2 @@",
+			" a",
+			"-b",
+			noNewlineMarker,
+			"+b",
+		}, unifiedDiff("old", "new", splitLines("a\nb"), splitLines("a\nb\n")))
+	})
+
+	t.Run("new file", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"--- old",
+			"+++ new",
+			"@@ -0,0 +1 @@",
+			"+[default]",
+		}, unifiedDiff("old", "new", nil, []string{"[default]"}))
+	})
+}
+
+func TestSplitLines(t *testing.T) {
+	assert.Nil(t, splitLines(""))
+	assert.Equal(t, []string{"a", "b"}, splitLines("a\nb\n"))
+	assert.Equal(t, []string{"a", "", "b\n" + noNewlineMarker}, splitLines("a\n\nb"))
+}
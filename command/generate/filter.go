@@ -0,0 +1,86 @@
+package generate
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// profileFilter decides which accounts and roles profiles are generated for.
+//
+// A target is kept when it matches no exclude rule and matches at least one
+// account include rule (name or ID) and at least one role include rule, where
+// either kind is given. Account rules are alternatives to each other, as are
+// role rules, while an account rule and a role rule must both match.
+type profileFilter struct {
+	include []appconfig.FilterRule
+	exclude []appconfig.FilterRule
+}
+
+// newProfileFilter returns a filter for the configured include and exclude rules
+func newProfileFilter(appCfg *appconfig.Config) (*profileFilter, error) {
+	include, err := appconfig.ParseFilterRules(appCfg.Generate.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := appconfig.ParseFilterRules(appCfg.Generate.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &profileFilter{include: include, exclude: exclude}, nil
+}
+
+// fieldValue returns the value a rule field is matched against. Role rules have
+// no value when only the account is known.
+func fieldValue(field string, account types.AccountInfo, roleName *string) (string, bool) {
+	switch field {
+	case appconfig.FilterFieldID:
+		return aws.ToString(account.AccountId), true
+	case appconfig.FilterFieldRole:
+		if roleName == nil {
+			return "", false
+		}
+		return *roleName, true
+	default:
+		return aws.ToString(account.AccountName), true
+	}
+}
+
+func (f *profileFilter) allows(account types.AccountInfo, roleName *string) bool {
+	for _, rule := range f.exclude {
+		if value, ok := fieldValue(rule.Field, account, roleName); ok && rule.Matches(value) {
+			return false
+		}
+	}
+
+	// Account name and ID rules both select accounts, so they share a group
+	included := make(map[bool]bool)
+	for _, rule := range f.include {
+		value, ok := fieldValue(rule.Field, account, roleName)
+		if !ok {
+			continue
+		}
+		isRole := rule.Field == appconfig.FilterFieldRole
+		included[isRole] = included[isRole] || rule.Matches(value)
+	}
+	for _, matched := range included {
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AllowsAccount reports whether any profile may be generated for the account,
+// considering only account name and ID rules. It is used to skip listing the
+// roles of accounts which are filtered out.
+func (f *profileFilter) AllowsAccount(account types.AccountInfo) bool {
+	return f.allows(account, nil)
+}
+
+// Allows reports whether a profile should be generated for the target
+func (f *profileFilter) Allows(target profileTarget) bool {
+	return f.allows(target.Account, &target.RoleName)
+}
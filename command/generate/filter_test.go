@@ -0,0 +1,54 @@
+package generate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+func TestProfileFilter(t *testing.T) {
+	appCfg := appconfig.Default()
+	appCfg.Generate.Include = []string{"prod-*", "id:333333333333", "role:ReadOnly*"}
+	appCfg.Generate.Exclude = []string{"prod-legacy", "role:/Admin$/"}
+
+	filter, err := newProfileFilter(appCfg)
+	require.NoError(t, err)
+
+	tests := []struct {
+		target  profileTarget
+		allowed bool
+	}{
+		{testTarget("111111111111", "prod-web", "ReadOnlyAccess"), true},
+		{testTarget("333333333333", "shared", "ReadOnly"), true},
+		{testTarget("111111111111", "prod-web", "Admin"), false},
+		{testTarget("222222222222", "prod-legacy", "ReadOnly"), false},
+		{testTarget("444444444444", "sandbox", "ReadOnly"), false},
+		{testTarget("111111111111", "prod-web", "Developer"), false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, filter.Allows(tt.target), "%s/%s", *tt.target.Account.AccountName, tt.target.RoleName)
+	}
+
+	// Role rules are ignored when only the account is known
+	assert.True(t, filter.AllowsAccount(testTarget("111111111111", "prod-web", "").Account))
+	assert.False(t, filter.AllowsAccount(testTarget("222222222222", "prod-legacy", "").Account))
+	assert.False(t, filter.AllowsAccount(testTarget("444444444444", "sandbox", "").Account))
+}
+
+func TestProfileFilterEmpty(t *testing.T) {
+	filter, err := newProfileFilter(appconfig.Default())
+	require.NoError(t, err)
+	assert.True(t, filter.Allows(testTarget("111111111111", "anything", "AnyRole")))
+}
+
+func TestProfileFilterInvalidRule(t *testing.T) {
+	appCfg := appconfig.Default()
+	appCfg.Generate.Exclude = []string{"id:["}
+
+	_, err := newProfileFilter(appCfg)
+	assert.Error(t, err)
+}
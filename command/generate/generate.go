@@ -1,16 +1,16 @@
 package generate
 
 import (
-	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
 	"os"
-	"os/exec"
-	"time"
+	"path/filepath"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
 	"github.com/bigkevmcd/go-configparser"
 	"github.com/mitchellh/cli"
 
@@ -21,14 +21,15 @@ import (
 
 // TokenGenerator interface for mocking
 type TokenGenerator interface {
-	GenerateTokenWithConfig(cfg aws.Config, appCfg *appconfig.Config) *string
+	GenerateTokenWithConfig(ui cli.Ui, cfg aws.Config, appCfg *appconfig.Config) (*string, error)
 }
 
-// DefaultTokenGenerator implements TokenGenerator using the real AWS functions
+// DefaultTokenGenerator implements TokenGenerator with the AWS SSO authenticator:
+// cached tokens, then refreshed tokens, then an interactive login
 type DefaultTokenGenerator struct{}
 
-func (g *DefaultTokenGenerator) GenerateTokenWithConfig(cfg aws.Config, appCfg *appconfig.Config) *string {
-	return awsprovider.GenerateTokenWithConfig(cfg, appCfg)
+func (g *DefaultTokenGenerator) GenerateTokenWithConfig(ui cli.Ui, cfg aws.Config, appCfg *appconfig.Config) (*string, error) {
+	return awsprovider.GenerateTokenWithConfig(ui, cfg, appCfg)
 }
 
 type cmd struct {
@@ -37,12 +38,22 @@ type cmd struct {
 	help  string
 
 	diff       bool
+	color      bool
+	dryRun     bool
+	confirm    bool
+	yes        bool
+	allRoles   bool
+	prune      bool
+	noBrowser  bool
+	credProc   bool
+	include    flags.StringSliceValue
+	exclude    flags.StringSliceValue
 	configFile string
 
 	// Dependencies for testing
 	ssoClientFactory func(aws.Config) SSOClient
 	tokenGenerator   TokenGenerator
-	configLoader     func() aws.Config
+	configLoader     func() (aws.Config, error)
 }
 
 func New(ui cli.Ui) *cmd {
@@ -58,7 +69,7 @@ func New(ui cli.Ui) *cmd {
 }
 
 // NewWithDependencies creates a new command with injected dependencies for testing
-func NewWithDependencies(ui cli.Ui, ssoClientFactory func(aws.Config) SSOClient, tokenGenerator TokenGenerator, configLoader func() aws.Config) *cmd {
+func NewWithDependencies(ui cli.Ui, ssoClientFactory func(aws.Config) SSOClient, tokenGenerator TokenGenerator, configLoader func() (aws.Config, error)) *cmd {
 	c := &cmd{UI: ui}
 	c.Init()
 	c.ssoClientFactory = ssoClientFactory
@@ -70,6 +81,16 @@ func NewWithDependencies(ui cli.Ui, ssoClientFactory func(aws.Config) SSOClient,
 func (c *cmd) Init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
 	c.flags.BoolVar(&c.diff, "diff", false, "Enable diff output.")
+	c.flags.BoolVar(&c.color, "color", false, "Colorize diff output.")
+	c.flags.BoolVar(&c.dryRun, "dry-run", false, "Show what would change without writing the AWS config file.")
+	c.flags.BoolVar(&c.confirm, "confirm", false, "Ask for confirmation before writing the AWS config file.")
+	c.flags.BoolVar(&c.yes, "yes", false, "Apply changes without asking for confirmation.")
+	c.flags.BoolVar(&c.allRoles, "all-roles", false, "Generate a profile for every role in every account.")
+	c.flags.BoolVar(&c.prune, "prune", false, "Remove previously generated profiles that are no longer generated.")
+	c.flags.BoolVar(&c.noBrowser, "no-browser", false, "Print the login URL and code instead of opening a browser.")
+	c.flags.BoolVar(&c.credProc, "credential-process", false, "Write a credential_process running aws-sso-config into every profile.")
+	c.flags.Var(&c.include, "include", "Only generate profiles matching this rule. Can be repeated.")
+	c.flags.Var(&c.exclude, "exclude", "Do not generate profiles matching this rule. Can be repeated.")
 	c.flags.StringVar(&c.configFile, "config", "", "Path to configuration file.")
 
 	c.help = flags.Usage(help, c.flags)
@@ -81,18 +102,36 @@ func (c *cmd) Run(args []string) int {
 	}
 
 	// Load configuration
-	var appCfg *appconfig.Config
-	var err error
+	appCfg, err := appconfig.LoadOrDefault(c.configFile)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Configuration error: %v", err))
+		return 1
+	}
 
-	if c.configFile != "" {
-		appCfg, err = appconfig.Load(c.configFile)
-		if err != nil {
-			c.UI.Error(fmt.Sprintf("Configuration error: %v", err))
-			return 1
-		}
-	} else {
-		appCfg = appconfig.Default()
+	// Command-line flags take precedence over the configuration file
+	if c.allRoles {
+		appCfg.Generate.AllRoles = true
+	}
+	if c.prune {
+		appCfg.Generate.Prune = true
+	}
+	if c.dryRun {
+		appCfg.Generate.DryRun = true
+	}
+	if c.confirm {
+		appCfg.Generate.Confirm = true
+	}
+	if c.yes {
+		appCfg.Generate.Confirm = false
 	}
+	if c.noBrowser {
+		appCfg.Login.Headless = true
+	}
+	if c.credProc {
+		appCfg.Generate.CredentialProcess = true
+	}
+	appCfg.Generate.Include = append(appCfg.Generate.Include, c.include...)
+	appCfg.Generate.Exclude = append(appCfg.Generate.Exclude, c.exclude...)
 
 	if err := appCfg.Validate(); err != nil {
 		c.UI.Error(fmt.Sprintf("Configuration error: %v", err))
@@ -101,16 +140,35 @@ func (c *cmd) Run(args []string) int {
 
 	configFile := appCfg.ConfigFile()
 
-	cfg := c.configLoader()
-	token := c.tokenGenerator.GenerateTokenWithConfig(cfg, appCfg)
+	cfg, err := c.configLoader()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error loading AWS configuration: %v", err))
+		return 1
+	}
+	// The OIDC and portal endpoints are in the SSO region
+	cfg.Region = appCfg.SSORegion()
+
+	token, err := c.tokenGenerator.GenerateTokenWithConfig(c.UI, cfg, appCfg)
+	if err != nil {
+		message := awsprovider.LoginErrorMessage(err)
+		if errors.Is(err, awsprovider.ErrLoginCanceled) {
+			message += ", the AWS config file was not changed"
+		}
+		c.UI.Error(message)
+		return 1
+	}
 
 	// create sso client
 	ssoClient := c.ssoClientFactory(cfg)
 
-	if generateAwsConfigFile(ssoClient, token, configFile, c.diff, appCfg) != nil {
+	changes, err := c.generateAwsConfigFile(ssoClient, token, configFile, appCfg)
+	if err != nil {
 		return 1
 	}
 
+	if appCfg.Generate.DryRun && changes.Count() > 0 {
+		return exitChangesPending
+	}
 	return 0
 }
 
@@ -122,78 +180,220 @@ func (c *cmd) Help() string {
 	return c.help
 }
 
-func showFileDiff(file1, file2 string) {
-	// First check to make sure these are legit filenames so we don't confuse the "diff" command
-	for _, file := range []string{file1, file2} {
-		_, err := os.Stat(file)
+// showFileDiff writes a unified diff of two files to the UI
+func showFileDiff(ui cli.Ui, file1, file2 string, color bool) {
+	var contents [2][]string
+	for i, file := range []string{file1, file2} {
+		content, err := os.ReadFile(file)
 		if err != nil {
-			fmt.Printf("File %s does not exist\n", file)
+			ui.Error(fmt.Sprintf("Unable to read %s: %v", file, err))
 			return
 		}
+		contents[i] = splitLines(string(content))
+	}
+
+	lines := unifiedDiff(file1, file2, contents[0], contents[1])
+	if len(lines) == 0 {
+		ui.Output("No changes")
+		return
 	}
-	cmd := exec.Command("diff", file1, file2)
-	cmd.Stdout = os.Stdout
-	cmd.Run() // Ignore error as diff returns non-zero when files differ
+	if color {
+		lines = colorizeDiff(lines)
+	}
+	ui.Output(strings.Join(lines, "\n"))
 }
 
-func generateAwsConfigFile(ssoClient SSOClient, token *string, configFile string, diff bool, appCfg *appconfig.Config) error {
-	configFileNew := configFile + ".new"
+// profileTarget is a single account and role pair that a profile is generated for
+type profileTarget struct {
+	Account     types.AccountInfo
+	RoleName    string
+	ProfileName string
+}
 
-	awsConfig, err := configparser.NewConfigParserFromFile(configFile)
+// listProfileTargets returns the account and role pairs to generate profiles for,
+// after applying the configured include and exclude rules. Unless all roles are
+// requested, every account is paired with the configured SSO role.
+func listProfileTargets(generator *ConfigGenerator, ssoClient SSOClient, token *string, accounts []types.AccountInfo, appCfg *appconfig.Config) ([]profileTarget, error) {
+	filter, err := newProfileFilter(appCfg)
 	if err != nil {
-		log.Fatal(err)
-		return err
+		return nil, err
 	}
 
-	fmt.Println("Fetching list of all accounts for user")
+	var targets []profileTarget
+	for _, account := range accounts {
+		if !filter.AllowsAccount(account) {
+			continue
+		}
+
+		roleNames := []string{appCfg.SSORole()}
+		if appCfg.Generate.AllRoles {
+			roles, err := generator.GetAccountRolesWithClient(ssoClient, token, aws.ToString(account.AccountId))
+			if err != nil {
+				return nil, err
+			}
+			roleNames = roleNames[:0]
+			for _, role := range roles {
+				roleNames = append(roleNames, aws.ToString(role.RoleName))
+			}
+		}
+
+		for _, roleName := range roleNames {
+			target := profileTarget{Account: account, RoleName: roleName}
+			if filter.Allows(target) {
+				targets = append(targets, target)
+			}
+		}
+	}
+
+	return targets, nil
+}
+
+// generateAwsConfigFile adds or updates a profile for every target in the AWS
+// config file and returns the sections that changed. A dry run computes the
+// changes without writing the file.
+func (c *cmd) generateAwsConfigFile(ssoClient SSOClient, token *string, configFile string, appCfg *appconfig.Config) (configChanges, error) {
+	var changes configChanges
 
-	// Get accounts (simplified - no pagination for testing compatibility)
-	listAccountsInput := &sso.ListAccountsInput{
-		AccessToken: token,
+	awsConfig, err := configparser.NewConfigParserFromFile(configFile)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading %s: %v", configFile, err))
+		return changes, err
 	}
+	before := snapshotSections(awsConfig)
+
+	c.UI.Output("Fetching list of all accounts for user")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	generator := NewConfigGenerator(appCfg.SSOStartURL(), appCfg.SSORegion(), appCfg.DefaultRegion())
+	accounts, err := generator.ListAccountsWithClient(ssoClient, token)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error fetching accounts: %v", err))
+		return changes, err
+	}
 
-	accountsResult, err := ssoClient.ListAccounts(ctx, listAccountsInput)
+	targets, err := listProfileTargets(generator, ssoClient, token, accounts, appCfg)
 	if err != nil {
-		fmt.Printf("Error fetching accounts: %v\n", err)
-		return err
+		c.UI.Error(fmt.Sprintf("Error fetching account roles: %v", err))
+		return changes, err
 	}
 
-	for _, y := range accountsResult.AccountList {
-		// Add all accounts - users can configure filtering if needed
-		accountName := aws.ToString(y.AccountName)
+	if err := nameProfileTargets(targets, appCfg); err != nil {
+		c.UI.Error(fmt.Sprintf("Error naming profiles: %v", err))
+		return changes, err
+	}
+
+	// Sections written by this run, which are never pruned
+	keep := make(map[string]bool, len(targets)+1)
 
-		// Use the account name as-is for the profile name
-		// Users can customize this logic based on their naming conventions
-		profileName := accountName
-		section := "profile " + profileName
+	policy := appCfg.Generate.MergePolicy
+	appConfigFile := c.appConfigFile()
 
-		// check if profile already exists and update it
-		if !awsConfig.HasSection(section) {
-			fmt.Printf("Adding profile %v\n", profileName)
-			awsConfig.AddSection(section)
+	if appCfg.UsesSSOSession() {
+		section := sessionSectionName(appCfg)
+		if err := generator.WriteSectionToConfig(awsConfig, section, mergeValues(awsConfig, section, sessionValues(appCfg), policy)); err != nil {
+			c.UI.Error(fmt.Sprintf("Error writing [%s]: %v", section, err))
+			return changes, err
 		}
+		keep[section] = true
+	}
 
-		awsConfig.Set(section, "sso_account_id", aws.ToString(y.AccountId))
-		awsConfig.Set(section, "sso_role_name", appCfg.SSORole())
-		awsConfig.Set(section, "sso_region", appCfg.SSORegion())
-		awsConfig.Set(section, "sso_start_url", appCfg.SSOStartURL())
-		awsConfig.Set(section, "region", appCfg.DefaultRegion())
+	for _, target := range targets {
+		section := "profile " + target.ProfileName
+		keep[section] = true
+
+		if err := generator.WriteSectionToConfig(awsConfig, section, mergeValues(awsConfig, section, profileValues(target, appCfg, appConfigFile), policy)); err != nil {
+			c.UI.Error(fmt.Sprintf("Error writing [%s]: %v", section, err))
+			return changes, err
+		}
+		// Existing keys are never removed when they are preserved
+		if policy != appconfig.MergePolicyPreserveExisting {
+			removeStaleKeys(awsConfig, section, appCfg)
+		}
+	}
+
+	if appCfg.Generate.Prune {
+		pruneSections(awsConfig, keep)
 	}
 
-	err = awsConfig.SaveWithDelimiter(configFileNew, "=")
+	changes = compareSections(awsConfig, before)
+	reportChanges(c.UI, changes, appCfg.Generate.DryRun)
+
+	return changes, c.applyChanges(awsConfig, configFile, changes, appCfg)
+}
+
+// appConfigFile returns the absolute path of the app config file given with
+// -config, or "" when there is none. credential_process runs in any directory.
+func (c *cmd) appConfigFile() string {
+	if c.configFile == "" {
+		return ""
+	}
+	path, err := filepath.Abs(c.configFile)
 	if err != nil {
+		return c.configFile
+	}
+	return path
+}
+
+// applyChanges writes the generated config to the config file, showing the diff
+// and asking for confirmation first when enabled. A dry run only shows the diff.
+func (c *cmd) applyChanges(awsConfig *configparser.ConfigParser, configFile string, changes configChanges, appCfg *appconfig.Config) error {
+	if appCfg.Generate.DryRun {
+		if c.diff {
+			if err := c.showConfigDiff(awsConfig, configFile); err != nil {
+				c.UI.Error(err.Error())
+				return err
+			}
+		}
+		return nil
+	}
+
+	configFileNew := configFile + ".new"
+	err := awsConfig.SaveWithDelimiter(configFileNew, "=")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error saving %s: %v", configFileNew, err))
 		return fmt.Errorf("failed to save config file: %w", err)
 	}
-	if diff {
-		showFileDiff(configFile, configFileNew)
+	if c.diff {
+		showFileDiff(c.UI, configFile, configFileNew, c.color)
+	}
+	if appCfg.Generate.Confirm && changes.Count() > 0 && !c.confirmChanges(changes) {
+		os.Remove(configFileNew)
+		return nil
 	}
 	err = os.Rename(configFileNew, configFile)
 	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error replacing %s: %v", configFile, err))
 		return fmt.Errorf("failed to rename config file: %w", err)
 	}
 
 	return nil
 }
+
+// confirmChanges asks the user whether the changes should be applied
+func (c *cmd) confirmChanges(changes configChanges) bool {
+	answer, err := c.UI.Ask(fmt.Sprintf("Apply these %d changes? [y/N]", changes.Count()))
+	if err == nil {
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true
+		}
+	}
+	c.UI.Output("Aborted, no changes were applied")
+	return false
+}
+
+// showConfigDiff shows the diff between the config file and the generated config
+// without writing next to the config file
+func (c *cmd) showConfigDiff(awsConfig *configparser.ConfigParser, configFile string) error {
+	tmpFile, err := os.CreateTemp("", "aws-sso-config-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	if err := awsConfig.SaveWithDelimiter(tmpFile.Name(), "="); err != nil {
+		return fmt.Errorf("failed to render config file: %w", err)
+	}
+	showFileDiff(c.UI, configFile, tmpFile.Name(), c.color)
+	return nil
+}
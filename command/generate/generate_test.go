@@ -1,18 +1,24 @@
 package generate
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sso"
 	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/bigkevmcd/go-configparser"
 	"github.com/mitchellh/cli"
+	"github.com/mitchellh/go-homedir"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/blairham/aws-sso-config/command/config/set"
+	awsprovider "github.com/blairham/aws-sso-config/providers/aws"
 	appconfig "github.com/blairham/aws-sso-config/providers/config"
 )
 
@@ -75,8 +81,8 @@ config_file = "` + awsConfigFile + `"`
 		token: &token,
 	}
 
-	mockConfigLoader := func() aws.Config {
-		return aws.Config{}
+	mockConfigLoader := func() (aws.Config, error) {
+		return aws.Config{}, nil
 	}
 
 	c := NewWithDependencies(ui, mockSSOClientFactory, mockTokenGenerator, mockConfigLoader)
@@ -239,8 +245,8 @@ func TestRunError(t *testing.T) {
 		shouldFail: true, // This will cause the token generation to fail
 	}
 
-	mockConfigLoader := func() aws.Config {
-		return aws.Config{}
+	mockConfigLoader := func() (aws.Config, error) {
+		return aws.Config{}, nil
 	}
 
 	c := NewWithDependencies(ui, mockSSOClientFactory, mockTokenGenerator, mockConfigLoader)
@@ -343,8 +349,8 @@ config_file = "` + awsConfigFile + `"`
 		token: &token,
 	}
 
-	mockConfigLoader := func() aws.Config {
-		return aws.Config{}
+	mockConfigLoader := func() (aws.Config, error) {
+		return aws.Config{}, nil
 	}
 
 	c := NewWithDependencies(ui, mockSSOClientFactory, mockTokenGenerator, mockConfigLoader)
@@ -357,19 +363,634 @@ config_file = "` + awsConfigFile + `"`
 	mockSSOClient.AssertExpectations(t)
 }
 
+// TestRunWithPaginatedAccounts verifies every page of accounts is written to the config
+func TestRunWithPaginatedAccounts(t *testing.T) {
+	awsConfigFile, appConfigFile := writeTestConfigs(t, "")
+
+	mockSSOClient := &MockSSOClient{}
+	mockSSOClient.On("ListAccounts", mock.Anything, mock.MatchedBy(func(input *sso.ListAccountsInput) bool {
+		return input.NextToken == nil
+	})).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{
+			{AccountId: aws.String("111111111111"), AccountName: aws.String("first-page")},
+		},
+		NextToken: aws.String("next"),
+	}, nil).Once()
+	mockSSOClient.On("ListAccounts", mock.Anything, mock.MatchedBy(func(input *sso.ListAccountsInput) bool {
+		return aws.ToString(input.NextToken) == "next"
+	})).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{
+			{AccountId: aws.String("222222222222"), AccountName: aws.String("second-page")},
+		},
+	}, nil).Once()
+
+	c := newTestCommand(cli.NewMockUi(), mockSSOClient)
+	exitCode := c.Run([]string{"-config=" + appConfigFile})
+	assert.Equal(t, 0, exitCode)
+	mockSSOClient.AssertExpectations(t)
+
+	updatedContent, err := os.ReadFile(awsConfigFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(updatedContent), "[profile first-page]")
+	assert.Contains(t, string(updatedContent), "[profile second-page]")
+}
+
+// TestRunWithAllRoles verifies a profile is generated for every account and role pair
+func TestRunWithAllRoles(t *testing.T) {
+	awsConfigFile, appConfigFile := writeTestConfigs(t, "")
+
+	mockSSOClient := &MockSSOClient{}
+	mockSSOClient.On("ListAccounts", mock.Anything, mock.Anything).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{
+			{AccountId: aws.String("111111111111"), AccountName: aws.String("prod")},
+			{AccountId: aws.String("222222222222"), AccountName: aws.String("dev")},
+		},
+	}, nil)
+	mockSSOClient.On("ListAccountRoles", mock.Anything, mock.MatchedBy(func(input *sso.ListAccountRolesInput) bool {
+		return aws.ToString(input.AccountId) == "111111111111"
+	})).Return(&sso.ListAccountRolesOutput{
+		RoleList: []types.RoleInfo{
+			{RoleName: aws.String("ReadOnly")},
+			{RoleName: aws.String("Admin")},
+		},
+	}, nil)
+	mockSSOClient.On("ListAccountRoles", mock.Anything, mock.MatchedBy(func(input *sso.ListAccountRolesInput) bool {
+		return aws.ToString(input.AccountId) == "222222222222"
+	})).Return(&sso.ListAccountRolesOutput{
+		RoleList: []types.RoleInfo{
+			{RoleName: aws.String("Developer")},
+		},
+	}, nil)
+
+	c := newTestCommand(cli.NewMockUi(), mockSSOClient)
+	exitCode := c.Run([]string{"-config=" + appConfigFile, "-all-roles"})
+	assert.Equal(t, 0, exitCode)
+	mockSSOClient.AssertExpectations(t)
+
+	awsConfig, err := configparser.NewConfigParserFromFile(awsConfigFile)
+	require.NoError(t, err)
+
+	for profile, role := range map[string]string{
+		"profile prod-ReadOnly": "ReadOnly",
+		"profile prod-Admin":    "Admin",
+		"profile dev-Developer": "Developer",
+	} {
+		roleName, err := awsConfig.Get(profile, "sso_role_name")
+		require.NoError(t, err, profile)
+		assert.Equal(t, role, roleName)
+	}
+	assert.False(t, awsConfig.HasSection("profile prod"))
+	assert.False(t, awsConfig.HasSection("profile dev-TestRole"))
+}
+
+// writeTestConfigs writes an AWS config file and an app config file using it, with
+// extra appended to the app config, and returns both paths
+func writeTestConfigs(t *testing.T, extra string) (string, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+
+	awsConfigFile := filepath.Join(tmpDir, "aws-config")
+	err := os.WriteFile(awsConfigFile, []byte("[default]\nregion = us-east-1\n"), 0600)
+	require.NoError(t, err)
+
+	appConfigFile := filepath.Join(tmpDir, "app-config.toml")
+	configContent := `[sso]
+start_url = "https://test.awsapps.com/start"
+region = "us-west-2"
+role = "TestRole"
+
+[aws]
+default_region = "eu-west-1"
+config_file = "` + awsConfigFile + `"
+` + extra
+	err = os.WriteFile(appConfigFile, []byte(configContent), 0600)
+	require.NoError(t, err)
+
+	return awsConfigFile, appConfigFile
+}
+
+// newTestCommand returns a generate command backed by the given mock SSO client
+func newTestCommand(ui cli.Ui, ssoClient SSOClient) *cmd {
+	token := "mock-access-token"
+	return NewWithDependencies(ui,
+		func(cfg aws.Config) SSOClient { return ssoClient },
+		&MockTokenGenerator{token: &token},
+		func() (aws.Config, error) { return aws.Config{}, nil })
+}
+
+// TestRunWithProfileTemplate verifies profiles are named using the configured template
+func TestRunWithProfileTemplate(t *testing.T) {
+	awsConfigFile, appConfigFile := writeTestConfigs(t, `
+[generate]
+profile_template = "{{ .AccountName | lower | kebab }}-{{ .RoleName | lower }}"
+`)
+
+	mockSSOClient := &MockSSOClient{}
+	mockSSOClient.On("ListAccounts", mock.Anything, mock.Anything).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{
+			{AccountId: aws.String("123456789012"), AccountName: aws.String("Production Account")},
+		},
+	}, nil)
+
+	c := newTestCommand(cli.NewMockUi(), mockSSOClient)
+	exitCode := c.Run([]string{"-config=" + appConfigFile})
+	assert.Equal(t, 0, exitCode)
+
+	awsConfig, err := configparser.NewConfigParserFromFile(awsConfigFile)
+	require.NoError(t, err)
+	assert.True(t, awsConfig.HasSection("profile production-account-testrole"))
+	assert.False(t, awsConfig.HasSection("profile Production Account"))
+}
+
+// TestRunWithDuplicateProfileNames verifies nothing is written when profile names collide
+func TestRunWithDuplicateProfileNames(t *testing.T) {
+	awsConfigFile, appConfigFile := writeTestConfigs(t, `
+[generate]
+profile_template = "{{ .RoleName }}"
+`)
+
+	mockSSOClient := &MockSSOClient{}
+	mockSSOClient.On("ListAccounts", mock.Anything, mock.Anything).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{
+			{AccountId: aws.String("111111111111"), AccountName: aws.String("prod")},
+			{AccountId: aws.String("222222222222"), AccountName: aws.String("dev")},
+		},
+	}, nil)
+
+	c := newTestCommand(cli.NewMockUi(), mockSSOClient)
+	exitCode := c.Run([]string{"-config=" + appConfigFile})
+	assert.Equal(t, 1, exitCode)
+
+	content, err := os.ReadFile(awsConfigFile)
+	require.NoError(t, err)
+	assert.Equal(t, "[default]\nregion = us-east-1\n", string(content))
+}
+
+// TestRunWithFilters verifies include and exclude rules from the config and flags are applied
+func TestRunWithFilters(t *testing.T) {
+	awsConfigFile, appConfigFile := writeTestConfigs(t, `
+[generate]
+exclude = ["sandbox-*"]
+`)
+
+	mockSSOClient := &MockSSOClient{}
+	mockSSOClient.On("ListAccounts", mock.Anything, mock.Anything).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{
+			{AccountId: aws.String("111111111111"), AccountName: aws.String("prod")},
+			{AccountId: aws.String("222222222222"), AccountName: aws.String("sandbox-alice")},
+			{AccountId: aws.String("333333333333"), AccountName: aws.String("legacy")},
+		},
+	}, nil)
+	mockSSOClient.On("ListAccountRoles", mock.Anything, mock.MatchedBy(func(input *sso.ListAccountRolesInput) bool {
+		return aws.ToString(input.AccountId) == "111111111111"
+	})).Return(&sso.ListAccountRolesOutput{
+		RoleList: []types.RoleInfo{
+			{RoleName: aws.String("ReadOnly")},
+			{RoleName: aws.String("Admin")},
+		},
+	}, nil)
+
+	c := newTestCommand(cli.NewMockUi(), mockSSOClient)
+	exitCode := c.Run([]string{"-config=" + appConfigFile, "-all-roles", "-exclude", "id:333333333333", "-include", "role:ReadOnly"})
+	assert.Equal(t, 0, exitCode)
+
+	// Roles of excluded accounts are never listed
+	mockSSOClient.AssertExpectations(t)
+	mockSSOClient.AssertNumberOfCalls(t, "ListAccountRoles", 1)
+
+	awsConfig, err := configparser.NewConfigParserFromFile(awsConfigFile)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"default", "profile prod-ReadOnly"}, awsConfig.Sections())
+}
+
+// TestRunWithSSOSessionFormat verifies profiles reference a single sso-session section
+func TestRunWithSSOSessionFormat(t *testing.T) {
+	awsConfigFile, appConfigFile := writeTestConfigs(t, `
+[generate]
+profile_format = "sso-session"
+`)
+	// An existing legacy profile is converted to the session format
+	err := os.WriteFile(awsConfigFile, []byte(`[profile prod]
+sso_account_id = 111111111111
+sso_role_name = TestRole
+sso_start_url = https://test.awsapps.com/start
+sso_region = us-west-2
+output = json
+`), 0600)
+	require.NoError(t, err)
+
+	mockSSOClient := &MockSSOClient{}
+	mockSSOClient.On("ListAccounts", mock.Anything, mock.Anything).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{
+			{AccountId: aws.String("111111111111"), AccountName: aws.String("prod")},
+			{AccountId: aws.String("222222222222"), AccountName: aws.String("dev")},
+		},
+	}, nil)
+
+	c := newTestCommand(cli.NewMockUi(), mockSSOClient)
+	exitCode := c.Run([]string{"-config=" + appConfigFile})
+	assert.Equal(t, 0, exitCode)
+
+	awsConfig, err := configparser.NewConfigParserFromFile(awsConfigFile)
+	require.NoError(t, err)
+
+	session, err := awsConfig.Items("sso-session aws-sso-config")
+	require.NoError(t, err)
+	assert.Equal(t, "https://test.awsapps.com/start", session["sso_start_url"])
+	assert.Equal(t, "us-west-2", session["sso_region"])
+	assert.Equal(t, "sso:account:access", session["sso_registration_scopes"])
+
+	for _, profile := range []string{"profile prod", "profile dev"} {
+		items, err := awsConfig.Items(profile)
+		require.NoError(t, err)
+		assert.Equal(t, "aws-sso-config", items["sso_session"], profile)
+		assert.NotContains(t, items, "sso_start_url", profile)
+		assert.NotContains(t, items, "sso_region", profile)
+	}
+
+	output, err := awsConfig.Get("profile prod", "output")
+	require.NoError(t, err)
+	assert.Equal(t, "json", output)
+}
+
+// TestRunWithPrune verifies stale generated profiles are removed and hand-written ones are kept
+func TestRunWithPrune(t *testing.T) {
+	awsConfigFile, appConfigFile := writeTestConfigs(t, "")
+	err := os.WriteFile(awsConfigFile, []byte(`[profile closed]
+sso_account_id = 333333333333
+sso_role_name = TestRole
+generated_by = aws-sso-config
+
+[profile personal]
+sso_account_id = 444444444444
+sso_role_name = TestRole
+`), 0600)
+	require.NoError(t, err)
+
+	mockSSOClient := &MockSSOClient{}
+	mockSSOClient.On("ListAccounts", mock.Anything, mock.Anything).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{
+			{AccountId: aws.String("111111111111"), AccountName: aws.String("prod")},
+		},
+	}, nil)
+
+	// Without -prune stale profiles are left in place
+	c := newTestCommand(cli.NewMockUi(), mockSSOClient)
+	exitCode := c.Run([]string{"-config=" + appConfigFile})
+	assert.Equal(t, 0, exitCode)
+
+	awsConfig, err := configparser.NewConfigParserFromFile(awsConfigFile)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"profile closed", "profile personal", "profile prod"}, awsConfig.Sections())
+
+	c = newTestCommand(cli.NewMockUi(), mockSSOClient)
+	exitCode = c.Run([]string{"-config=" + appConfigFile, "-prune"})
+	assert.Equal(t, 0, exitCode)
+
+	awsConfig, err = configparser.NewConfigParserFromFile(awsConfigFile)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"profile personal", "profile prod"}, awsConfig.Sections())
+
+	marker, err := awsConfig.Get("profile prod", "generated_by")
+	require.NoError(t, err)
+	assert.Equal(t, "aws-sso-config", marker)
+}
+
+// TestRunWithDiff verifies the diff is written through the UI
+func TestRunWithDiff(t *testing.T) {
+	_, appConfigFile := writeTestConfigs(t, "")
+
+	mockSSOClient := &MockSSOClient{}
+	mockSSOClient.On("ListAccounts", mock.Anything, mock.Anything).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{
+			{AccountId: aws.String("111111111111"), AccountName: aws.String("prod")},
+		},
+	}, nil)
+
+	ui := cli.NewMockUi()
+	c := newTestCommand(ui, mockSSOClient)
+	exitCode := c.Run([]string{"-config=" + appConfigFile, "-diff"})
+	assert.Equal(t, 0, exitCode)
+
+	output := ui.OutputWriter.String()
+	assert.Contains(t, output, "Adding profile prod")
+	assert.Contains(t, output, " region = us-east-1\n")
+	assert.Contains(t, output, "+[profile prod]\n")
+	assert.Contains(t, output, "+sso_account_id = 111111111111\n")
+}
+
+// TestRunWithDryRun verifies a dry run reports changes without writing the config file
+func TestRunWithDryRun(t *testing.T) {
+	awsConfigFile, appConfigFile := writeTestConfigs(t, "")
+
+	mockSSOClient := &MockSSOClient{}
+	mockSSOClient.On("ListAccounts", mock.Anything, mock.Anything).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{
+			{AccountId: aws.String("111111111111"), AccountName: aws.String("prod")},
+		},
+	}, nil)
+
+	ui := cli.NewMockUi()
+	c := newTestCommand(ui, mockSSOClient)
+	exitCode := c.Run([]string{"-config=" + appConfigFile, "-dry-run", "-diff"})
+	assert.Equal(t, exitChangesPending, exitCode)
+
+	output := ui.OutputWriter.String()
+	assert.Contains(t, output, "Would add profile prod")
+	assert.Contains(t, output, "Dry run: 1 added, 0 updated, 0 removed")
+	assert.Contains(t, output, "+sso_account_id = 111111111111")
+
+	content, err := os.ReadFile(awsConfigFile)
+	require.NoError(t, err)
+	assert.Equal(t, "[default]\nregion = us-east-1\n", string(content))
+	_, err = os.Stat(awsConfigFile + ".new")
+	assert.True(t, os.IsNotExist(err))
+
+	// Once applied, a dry run has nothing to do
+	c = newTestCommand(cli.NewMockUi(), mockSSOClient)
+	assert.Equal(t, 0, c.Run([]string{"-config=" + appConfigFile}))
+
+	ui = cli.NewMockUi()
+	c = newTestCommand(ui, mockSSOClient)
+	exitCode = c.Run([]string{"-config=" + appConfigFile, "-dry-run"})
+	assert.Equal(t, 0, exitCode)
+	assert.Contains(t, ui.OutputWriter.String(), "Dry run: no changes")
+}
+
+// TestRunWithConfirm verifies changes are only written once confirmed
+func TestRunWithConfirm(t *testing.T) {
+	const original = "[default]\nregion = us-east-1\n"
+
+	mockSSOClient := &MockSSOClient{}
+	mockSSOClient.On("ListAccounts", mock.Anything, mock.Anything).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{
+			{AccountId: aws.String("111111111111"), AccountName: aws.String("prod")},
+		},
+	}, nil)
+
+	tests := []struct {
+		name     string
+		extra    string
+		args     []string
+		input    string
+		exitCode int
+		written  bool
+		prompted bool
+	}{
+		{"declined", "", []string{"-confirm"}, "n\n", 0, false, true},
+		{"no answer", "", []string{"-confirm"}, "", 0, false, true},
+		{"accepted", "", []string{"-confirm"}, "y\n", 0, true, true},
+		{"enabled in config", "[generate]\nconfirm = true\n", nil, "yes\n", 0, true, true},
+		{"skipped with -yes", "[generate]\nconfirm = true\n", []string{"-yes"}, "", 0, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			awsConfigFile, appConfigFile := writeTestConfigs(t, tt.extra)
+
+			ui := cli.NewMockUi()
+			ui.InputReader = strings.NewReader(tt.input)
+			c := newTestCommand(ui, mockSSOClient)
+			exitCode := c.Run(append([]string{"-config=" + appConfigFile}, tt.args...))
+			assert.Equal(t, tt.exitCode, exitCode)
+
+			if tt.prompted {
+				assert.Contains(t, ui.OutputWriter.String(), "Apply these 1 changes? [y/N]")
+			} else {
+				assert.NotContains(t, ui.OutputWriter.String(), "Apply these")
+			}
+
+			content, err := os.ReadFile(awsConfigFile)
+			require.NoError(t, err)
+			if tt.written {
+				assert.Contains(t, string(content), "[profile prod]")
+			} else {
+				assert.Equal(t, original, string(content))
+				assert.Contains(t, ui.OutputWriter.String(), "Aborted, no changes were applied")
+			}
+			_, err = os.Stat(awsConfigFile + ".new")
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
+// TestRunWithMergePolicy verifies local edits to existing profiles survive regeneration
+func TestRunWithMergePolicy(t *testing.T) {
+	const existing = `[profile prod]
+sso_account_id = 111111111111
+sso_role_name = OldRole
+sso_start_url = https://test.awsapps.com/start
+sso_region = us-west-2
+region = ap-south-1
+output = json
+`
+
+	mockSSOClient := &MockSSOClient{}
+	mockSSOClient.On("ListAccounts", mock.Anything, mock.Anything).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{
+			{AccountId: aws.String("111111111111"), AccountName: aws.String("prod")},
+		},
+	}, nil)
+
+	tests := []struct {
+		policy string
+		role   string
+		region string
+	}{
+		{"overwrite", "TestRole", "eu-west-1"},
+		{"preserve-existing", "OldRole", "ap-south-1"},
+		{"only-managed-keys", "TestRole", "ap-south-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			awsConfigFile, appConfigFile := writeTestConfigs(t, "[generate]\nmerge_policy = \""+tt.policy+"\"\n")
+			require.NoError(t, os.WriteFile(awsConfigFile, []byte(existing), 0600))
+
+			c := newTestCommand(cli.NewMockUi(), mockSSOClient)
+			exitCode := c.Run([]string{"-config=" + appConfigFile})
+			assert.Equal(t, 0, exitCode)
+
+			awsConfig, err := configparser.NewConfigParserFromFile(awsConfigFile)
+			require.NoError(t, err)
+			items, err := awsConfig.Items("profile prod")
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.role, items["sso_role_name"])
+			assert.Equal(t, tt.region, items["region"])
+			assert.Equal(t, "json", items["output"])
+			assert.Equal(t, "aws-sso-config", items["generated_by"])
+		})
+	}
+}
+
+// TestRunWithConfigSetValues verifies values saved with config set are used when no
+// -config is given
+func TestRunWithConfigSetValues(t *testing.T) {
+	homedir.DisableCache = true
+	t.Cleanup(func() { homedir.DisableCache = false })
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	awsConfigFile := filepath.Join(home, "aws-config")
+	require.NoError(t, os.WriteFile(awsConfigFile, []byte(`[profile prod-testrole]
+sso_account_id = 111111111111
+sso_role_name = OldRole
+`), 0600))
+
+	for _, kv := range [][2]string{
+		{"sso.start_url", "https://test.awsapps.com/start"},
+		{"sso.region", "us-west-2"},
+		{"sso.role", "TestRole"},
+		{"aws.config_file", awsConfigFile},
+		{"generate.profile_template", "{{ .AccountName }}-{{ .RoleName | lower }}"},
+		{"generate.exclude", "sandbox-*"},
+		{"generate.merge_policy", "preserve-existing"},
+	} {
+		require.Equal(t, 0, set.New(cli.NewMockUi()).Run(kv[:]), kv[0])
+	}
+
+	mockSSOClient := &MockSSOClient{}
+	mockSSOClient.On("ListAccounts", mock.Anything, mock.Anything).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{
+			{AccountId: aws.String("111111111111"), AccountName: aws.String("prod")},
+			{AccountId: aws.String("222222222222"), AccountName: aws.String("sandbox-alice")},
+		},
+	}, nil)
+
+	c := newTestCommand(cli.NewMockUi(), mockSSOClient)
+	assert.Equal(t, 0, c.Run([]string{}))
+
+	awsConfig, err := configparser.NewConfigParserFromFile(awsConfigFile)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"profile prod-testrole"}, awsConfig.Sections())
+	items, err := awsConfig.Items("profile prod-testrole")
+	require.NoError(t, err)
+	assert.Equal(t, "OldRole", items["sso_role_name"])
+}
+
+func TestRunLoginErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"canceled", awsprovider.ErrLoginCanceled, "Login canceled"},
+		{"expired", awsprovider.ErrAuthorizationExpired, "Login failed: authorization timed out"},
+		{"network", errors.New("failed to register client: dial tcp: no route to host"), "Error logging in to AWS SSO: failed to register client"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			awsConfigFile, appConfigFile := writeTestConfigs(t, "")
+			ui := cli.NewMockUi()
+			ssoClient := new(MockSSOClient)
+			c := NewWithDependencies(ui,
+				func(cfg aws.Config) SSOClient { return ssoClient },
+				&MockTokenGenerator{err: tt.err},
+				func() (aws.Config, error) { return aws.Config{}, nil })
+
+			assert.Equal(t, 1, c.Run([]string{"-config=" + appConfigFile}))
+			assert.Contains(t, ui.ErrorWriter.String(), tt.expected)
+
+			// Nothing is fetched or written without a token
+			ssoClient.AssertNotCalled(t, "ListAccounts", mock.Anything, mock.Anything, mock.Anything)
+			content, err := os.ReadFile(awsConfigFile)
+			require.NoError(t, err)
+			assert.Equal(t, "[default]\nregion = us-east-1\n", string(content))
+		})
+	}
+}
+
+func TestRunConfigLoaderError(t *testing.T) {
+	_, appConfigFile := writeTestConfigs(t, "")
+	ui := cli.NewMockUi()
+	c := NewWithDependencies(ui,
+		func(cfg aws.Config) SSOClient { return new(MockSSOClient) },
+		&MockTokenGenerator{},
+		func() (aws.Config, error) { return aws.Config{}, errors.New("invalid shared config") })
+
+	assert.Equal(t, 1, c.Run([]string{"-config=" + appConfigFile}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Error loading AWS configuration: invalid shared config")
+}
+
+func TestRunUnreadableAWSConfig(t *testing.T) {
+	awsConfigFile, appConfigFile := writeTestConfigs(t, "")
+	require.NoError(t, os.Remove(awsConfigFile))
+
+	// A missing AWS config file is reported instead of exiting the process
+	ui := cli.NewMockUi()
+	c := newTestCommand(ui, new(MockSSOClient))
+	assert.Equal(t, 1, c.Run([]string{"-config=" + appConfigFile}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Error reading "+awsConfigFile)
+}
+
+func TestRunSaveError(t *testing.T) {
+	mockSSOClient := &MockSSOClient{}
+	mockSSOClient.On("ListAccounts", mock.Anything, mock.Anything).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{
+			{AccountId: aws.String("111111111111"), AccountName: aws.String("prod")},
+		},
+	}, nil)
+
+	awsConfigFile, appConfigFile := writeTestConfigs(t, "")
+	// The new config file cannot be written over a directory
+	require.NoError(t, os.Mkdir(awsConfigFile+".new", 0700))
+
+	ui := cli.NewMockUi()
+	c := newTestCommand(ui, mockSSOClient)
+	assert.Equal(t, 1, c.Run([]string{"-config=" + appConfigFile}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Error saving "+awsConfigFile+".new")
+}
+
 // MockTokenGenerator implements TokenGenerator for testing
 type MockTokenGenerator struct {
 	shouldFail bool
+	err        error
 	token      *string
 }
 
-func (m *MockTokenGenerator) GenerateTokenWithConfig(cfg aws.Config, appCfg *appconfig.Config) *string {
+func (m *MockTokenGenerator) GenerateTokenWithConfig(ui cli.Ui, cfg aws.Config, appCfg *appconfig.Config) (*string, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
 	if m.shouldFail {
-		return nil
+		return nil, errors.New("mock token generation failure")
 	}
 	if m.token != nil {
-		return m.token
+		return m.token, nil
 	}
 	token := "mock-token"
-	return &token
+	return &token, nil
+}
+
+func TestRunWithCredentialProcess(t *testing.T) {
+	mockSSOClient := &MockSSOClient{}
+	mockSSOClient.On("ListAccounts", mock.Anything, mock.Anything).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{
+			{AccountId: aws.String("111111111111"), AccountName: aws.String("prod")},
+		},
+	}, nil)
+
+	awsConfigFile, appConfigFile := writeTestConfigs(t, "")
+
+	c := newTestCommand(cli.NewMockUi(), mockSSOClient)
+	assert.Equal(t, 0, c.Run([]string{"-config=" + appConfigFile, "-credential-process"}))
+
+	awsConfig, err := configparser.NewConfigParserFromFile(awsConfigFile)
+	require.NoError(t, err)
+	items, err := awsConfig.Items("profile prod")
+	require.NoError(t, err)
+	assert.Equal(t, "aws-sso-config credential-process --config "+appConfigFile+" --profile prod", items["credential_process"])
+
+	// Generating again without the option removes the key it wrote
+	c = newTestCommand(cli.NewMockUi(), mockSSOClient)
+	assert.Equal(t, 0, c.Run([]string{"-config=" + appConfigFile}))
+
+	awsConfig, err = configparser.NewConfigParserFromFile(awsConfigFile)
+	require.NoError(t, err)
+	items, err = awsConfig.Items("profile prod")
+	require.NoError(t, err)
+	_, ok := items["credential_process"]
+	assert.False(t, ok)
 }
@@ -51,18 +51,23 @@ func (g *ConfigGenerator) ListAccountsWithClient(ssoClient SSOClient, token *str
 		return nil, errors.New("no SSO token provided")
 	}
 
-	// List accounts
+	// List accounts, following NextToken until every page has been read
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	accountsOutput, err := ssoClient.ListAccounts(ctx, &sso.ListAccountsInput{
+	var accounts []types.AccountInfo
+	paginator := sso.NewListAccountsPaginator(ssoClient, &sso.ListAccountsInput{
 		AccessToken: token,
 	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list accounts: %w", err)
+		}
+		accounts = append(accounts, page.AccountList...)
 	}
 
-	return accountsOutput.AccountList, nil
+	return accounts, nil
 }
 
 // GetAccountRolesWithClient gets AWS account roles using the provided SSO client
@@ -71,19 +76,24 @@ func (g *ConfigGenerator) GetAccountRolesWithClient(ssoClient SSOClient, token *
 		return nil, errors.New("no SSO token provided")
 	}
 
-	// List roles for this account
+	// List roles for this account, following NextToken until every page has been read
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	rolesOutput, err := ssoClient.ListAccountRoles(ctx, &sso.ListAccountRolesInput{
+	var roles []types.RoleInfo
+	paginator := sso.NewListAccountRolesPaginator(ssoClient, &sso.ListAccountRolesInput{
 		AccessToken: token,
 		AccountId:   aws.String(accountID),
 	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list roles for account %s: %w", accountID, err)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list roles for account %s: %w", accountID, err)
+		}
+		roles = append(roles, page.RoleList...)
 	}
 
-	return rolesOutput.RoleList, nil
+	return roles, nil
 }
 
 // WriteSectionToConfig writes a section to the config parser
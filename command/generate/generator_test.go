@@ -252,3 +252,76 @@ func TestWriteSectionToConfigError(t *testing.T) {
 	// Verify the empty section was created
 	assert.True(t, configParser.HasSection("profile empty"))
 }
+
+func TestListAccountsWithClientPagination(t *testing.T) {
+	mockClient := new(MockSSOClient)
+	token := "test-token"
+
+	mockClient.On("ListAccounts", mock.Anything, mock.MatchedBy(func(input *sso.ListAccountsInput) bool {
+		return input.NextToken == nil
+	})).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{{AccountId: aws.String("111111111111"), AccountName: aws.String("Page One")}},
+		NextToken:   aws.String("page-2"),
+	}, nil).Once()
+	mockClient.On("ListAccounts", mock.Anything, mock.MatchedBy(func(input *sso.ListAccountsInput) bool {
+		return aws.ToString(input.NextToken) == "page-2"
+	})).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{{AccountId: aws.String("222222222222"), AccountName: aws.String("Page Two")}},
+	}, nil).Once()
+
+	generator := NewConfigGenerator("https://test.com", "us-west-2", "us-east-1")
+	accounts, err := generator.ListAccountsWithClient(mockClient, &token)
+
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+	assert.Equal(t, "111111111111", aws.ToString(accounts[0].AccountId))
+	assert.Equal(t, "222222222222", aws.ToString(accounts[1].AccountId))
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetAccountRolesWithClientPagination(t *testing.T) {
+	mockClient := new(MockSSOClient)
+	token := "test-token"
+	accountID := "123456789012"
+
+	mockClient.On("ListAccountRoles", mock.Anything, mock.MatchedBy(func(input *sso.ListAccountRolesInput) bool {
+		return input.NextToken == nil
+	})).Return(&sso.ListAccountRolesOutput{
+		RoleList:  []types.RoleInfo{{RoleName: aws.String("AdminRole"), AccountId: aws.String(accountID)}},
+		NextToken: aws.String("page-2"),
+	}, nil).Once()
+	mockClient.On("ListAccountRoles", mock.Anything, mock.MatchedBy(func(input *sso.ListAccountRolesInput) bool {
+		return aws.ToString(input.NextToken) == "page-2"
+	})).Return(&sso.ListAccountRolesOutput{
+		RoleList: []types.RoleInfo{{RoleName: aws.String("ReadOnlyRole"), AccountId: aws.String(accountID)}},
+	}, nil).Once()
+
+	generator := NewConfigGenerator("https://test.com", "us-west-2", "us-east-1")
+	roles, err := generator.GetAccountRolesWithClient(mockClient, &token, accountID)
+
+	require.NoError(t, err)
+	require.Len(t, roles, 2)
+	assert.Equal(t, "AdminRole", aws.ToString(roles[0].RoleName))
+	assert.Equal(t, "ReadOnlyRole", aws.ToString(roles[1].RoleName))
+	mockClient.AssertExpectations(t)
+}
+
+func TestListAccountsWithClientPaginationError(t *testing.T) {
+	mockClient := new(MockSSOClient)
+	token := "test-token"
+
+	mockClient.On("ListAccounts", mock.Anything, mock.MatchedBy(func(input *sso.ListAccountsInput) bool {
+		return input.NextToken == nil
+	})).Return(&sso.ListAccountsOutput{
+		AccountList: []types.AccountInfo{{AccountId: aws.String("111111111111")}},
+		NextToken:   aws.String("page-2"),
+	}, nil).Once()
+	mockClient.On("ListAccounts", mock.Anything, mock.Anything).Return(nil, errors.New("throttled")).Once()
+
+	generator := NewConfigGenerator("https://test.com", "us-west-2", "us-east-1")
+	accounts, err := generator.ListAccountsWithClient(mockClient, &token)
+
+	assert.Error(t, err)
+	assert.Nil(t, accounts)
+	mockClient.AssertExpectations(t)
+}
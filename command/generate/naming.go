@@ -0,0 +1,144 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// profileNameData is the data available to the profile name template
+type profileNameData struct {
+	AccountID   string
+	AccountName string
+	Email       string
+	RoleName    string
+	Region      string
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// separate replaces every run of non-alphanumeric characters with sep
+func separate(sep string) func(string) string {
+	return func(s string) string {
+		return strings.Trim(nonAlphanumeric.ReplaceAllString(s, sep), sep)
+	}
+}
+
+// profileNameFuncs are the functions available to the profile name template
+var profileNameFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+	"kebab": separate("-"),
+	"snake": separate("_"),
+	"replace": func(old, new, s string) string {
+		return strings.ReplaceAll(s, old, new)
+	},
+}
+
+// profileNamer renders profile names for account and role pairs
+type profileNamer struct {
+	tmpl    *template.Template
+	region  string
+	perRole bool
+}
+
+// newProfileNamer returns a namer for the configured profile template. When no
+// template is configured profiles are named after the account, with the role
+// appended when a profile is generated per role.
+func newProfileNamer(appCfg *appconfig.Config) (*profileNamer, error) {
+	namer := &profileNamer{
+		region:  appCfg.DefaultRegion(),
+		perRole: appCfg.Generate.AllRoles,
+	}
+
+	if appCfg.Generate.ProfileTemplate == "" {
+		return namer, nil
+	}
+
+	tmpl, err := template.New("profile").Funcs(profileNameFuncs).Option("missingkey=error").Parse(appCfg.Generate.ProfileTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid profile template: %w", err)
+	}
+	namer.tmpl = tmpl
+
+	return namer, nil
+}
+
+// Name renders the profile name for the given target
+func (n *profileNamer) Name(target profileTarget) (string, error) {
+	data := profileNameData{
+		AccountID:   aws.ToString(target.Account.AccountId),
+		AccountName: aws.ToString(target.Account.AccountName),
+		Email:       aws.ToString(target.Account.EmailAddress),
+		RoleName:    target.RoleName,
+		Region:      n.region,
+	}
+
+	if n.tmpl == nil {
+		if n.perRole {
+			return data.AccountName + "-" + data.RoleName, nil
+		}
+		return data.AccountName, nil
+	}
+
+	var buf bytes.Buffer
+	if err := n.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render profile name for account %s: %w", data.AccountID, err)
+	}
+
+	return buf.String(), nil
+}
+
+// validateProfileName checks that a profile name can be written as an INI section name
+func validateProfileName(name string) error {
+	if name == "" {
+		return fmt.Errorf("profile name is empty")
+	}
+	if strings.TrimSpace(name) != name {
+		return fmt.Errorf("profile name %q has leading or trailing whitespace", name)
+	}
+	for _, r := range name {
+		if r == '[' || r == ']' || unicode.IsControl(r) {
+			return fmt.Errorf("profile name %q contains invalid character %q", name, r)
+		}
+	}
+	return nil
+}
+
+// nameProfileTargets assigns a profile name to every target, ensuring that names
+// are valid and that no two targets share the same profile.
+func nameProfileTargets(targets []profileTarget, appCfg *appconfig.Config) error {
+	namer, err := newProfileNamer(appCfg)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]profileTarget, len(targets))
+	for i := range targets {
+		name, err := namer.Name(targets[i])
+		if err != nil {
+			return err
+		}
+		if err := validateProfileName(name); err != nil {
+			return err
+		}
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("profile name %q is generated for both %s/%s and %s/%s",
+				name,
+				aws.ToString(other.Account.AccountId), other.RoleName,
+				aws.ToString(targets[i].Account.AccountId), targets[i].RoleName)
+		}
+		seen[name] = targets[i]
+		targets[i].ProfileName = name
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package generate
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+func testTarget(accountID, accountName, roleName string) profileTarget {
+	return profileTarget{
+		Account: types.AccountInfo{
+			AccountId:    aws.String(accountID),
+			AccountName:  aws.String(accountName),
+			EmailAddress: aws.String("aws+" + accountID + "@example.com"),
+		},
+		RoleName: roleName,
+	}
+}
+
+func TestProfileNamerDefaults(t *testing.T) {
+	appCfg := appconfig.Default()
+	target := testTarget("123456789012", "Production Account", "ReadOnly")
+
+	namer, err := newProfileNamer(appCfg)
+	require.NoError(t, err)
+	name, err := namer.Name(target)
+	require.NoError(t, err)
+	assert.Equal(t, "Production Account", name)
+
+	appCfg.Generate.AllRoles = true
+	namer, err = newProfileNamer(appCfg)
+	require.NoError(t, err)
+	name, err = namer.Name(target)
+	require.NoError(t, err)
+	assert.Equal(t, "Production Account-ReadOnly", name)
+}
+
+func TestProfileNamerTemplate(t *testing.T) {
+	target := testTarget("123456789012", "Production Account (EU)", "ReadOnly")
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"{{ .AccountName | lower | kebab }}-{{ .RoleName }}", "production-account-eu-ReadOnly"},
+		{"{{ .AccountName | snake | upper }}", "PRODUCTION_ACCOUNT_EU"},
+		{"{{ .AccountID }}/{{ .RoleName | lower }}", "123456789012/readonly"},
+		{"{{ .Email }}", "aws+123456789012@example.com"},
+		{"{{ .AccountName | replace \" \" \"\" }}@{{ .Region }}", "ProductionAccount(EU)@eu-west-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			appCfg := appconfig.Default()
+			appCfg.AWS.DefaultRegion = "eu-west-1"
+			appCfg.Generate.ProfileTemplate = tt.template
+
+			namer, err := newProfileNamer(appCfg)
+			require.NoError(t, err)
+			name, err := namer.Name(target)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, name)
+		})
+	}
+}
+
+func TestProfileNamerInvalidTemplate(t *testing.T) {
+	appCfg := appconfig.Default()
+
+	appCfg.Generate.ProfileTemplate = "{{ .AccountName"
+	_, err := newProfileNamer(appCfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid profile template")
+
+	appCfg.Generate.ProfileTemplate = "{{ .Missing }}"
+	namer, err := newProfileNamer(appCfg)
+	require.NoError(t, err)
+	_, err = namer.Name(testTarget("123456789012", "prod", "Admin"))
+	assert.Error(t, err)
+}
+
+func TestValidateProfileName(t *testing.T) {
+	assert.NoError(t, validateProfileName("prod-admin"))
+	assert.NoError(t, validateProfileName("Production Account"))
+
+	for _, name := range []string{"", " prod", "prod ", "prod[1]", "prod\nadmin"} {
+		assert.Error(t, validateProfileName(name), "name %q should be invalid", name)
+	}
+}
+
+func TestNameProfileTargets(t *testing.T) {
+	appCfg := appconfig.Default()
+	appCfg.Generate.ProfileTemplate = "{{ .AccountName | lower | kebab }}-{{ .RoleName | lower }}"
+
+	targets := []profileTarget{
+		testTarget("111111111111", "Prod", "Admin"),
+		testTarget("222222222222", "Dev", "Admin"),
+	}
+	require.NoError(t, nameProfileTargets(targets, appCfg))
+	assert.Equal(t, "prod-admin", targets[0].ProfileName)
+	assert.Equal(t, "dev-admin", targets[1].ProfileName)
+
+	// Names which collide after rendering are rejected
+	targets = []profileTarget{
+		testTarget("111111111111", "Prod", "Admin"),
+		testTarget("222222222222", "prod", "Admin"),
+	}
+	err := nameProfileTargets(targets, appCfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `"prod-admin"`)
+	assert.Contains(t, err.Error(), "111111111111/Admin")
+	assert.Contains(t, err.Error(), "222222222222/Admin")
+
+	// Names which are not valid section names are rejected
+	appCfg.Generate.ProfileTemplate = "[{{ .AccountName }}]"
+	err = nameProfileTargets([]profileTarget{testTarget("111111111111", "Prod", "Admin")}, appCfg)
+	assert.Error(t, err)
+}
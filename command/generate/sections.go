@@ -0,0 +1,178 @@
+package generate
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/bigkevmcd/go-configparser"
+
+	awsprovider "github.com/blairham/aws-sso-config/providers/aws"
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// credentialProcessKey is the profile key running a command for credentials.
+// The commands generate writes start with credentialProcessCommand.
+const (
+	credentialProcessKey     = "credential_process"
+	credentialProcessCommand = "aws-sso-config credential-process"
+)
+
+// Keys which point a profile at the SSO portal in each profile format
+var (
+	legacySSOKeys  = []string{"sso_start_url", "sso_region"}
+	sessionSSOKeys = []string{"sso_session"}
+)
+
+// managedKeys are the keys generate owns in every section it writes. They identify
+// the account, role and SSO portal of a profile, and are kept up to date by every
+// merge policy except preserve-existing.
+var managedKeys = map[string]bool{
+	"sso_account_id":           true,
+	"sso_role_name":            true,
+	"sso_session":              true,
+	"sso_start_url":            true,
+	"sso_region":               true,
+	"sso_registration_scopes":  true,
+	credentialProcessKey:       true,
+	awsprovider.GeneratedByKey: true,
+}
+
+// sessionSectionName returns the name of the [sso-session] section profiles reference
+func sessionSectionName(appCfg *appconfig.Config) string {
+	return "sso-session " + appCfg.SSO.SessionName
+}
+
+// sessionValues returns the keys written to the [sso-session] section
+func sessionValues(appCfg *appconfig.Config) map[string]string {
+	return map[string]string{
+		"sso_start_url":            appCfg.SSOStartURL(),
+		"sso_region":               appCfg.SSORegion(),
+		"sso_registration_scopes":  appCfg.SSO.RegistrationScopes,
+		awsprovider.GeneratedByKey: awsprovider.GeneratedByValue,
+	}
+}
+
+// profileValues returns the keys written to the profile generated for the target.
+// appConfigFile is the app config file given with -config, or "" for the default.
+func profileValues(target profileTarget, appCfg *appconfig.Config, appConfigFile string) map[string]string {
+	values := map[string]string{
+		"sso_account_id":           aws.ToString(target.Account.AccountId),
+		"sso_role_name":            target.RoleName,
+		"region":                   appCfg.DefaultRegion(),
+		awsprovider.GeneratedByKey: awsprovider.GeneratedByValue,
+	}
+
+	if appCfg.UsesSSOSession() {
+		values["sso_session"] = appCfg.SSO.SessionName
+	} else {
+		values["sso_start_url"] = appCfg.SSOStartURL()
+		values["sso_region"] = appCfg.SSORegion()
+	}
+	if appCfg.Generate.CredentialProcess {
+		values[credentialProcessKey] = credentialProcess(target.ProfileName, appConfigFile)
+	}
+
+	return values
+}
+
+// credentialProcess returns the credential_process command printing credentials
+// for the profile, passing on the app config file when one was given
+func credentialProcess(profileName, appConfigFile string) string {
+	command := credentialProcessCommand
+	if appConfigFile != "" {
+		command += " --config " + shellWord(appConfigFile)
+	}
+	return command + " --profile " + shellWord(profileName)
+}
+
+// shellWord returns s as a single shell word, quoting it when the shell would
+// split or expand it
+func shellWord(s string) string {
+	plain := s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.@+/", r)
+	}) < 0
+	if plain {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(s) + `"`
+}
+
+// staleProfileKeys returns the keys of the profile format not in use, which are
+// removed from regenerated profiles so that switching formats leaves no mix of both
+func staleProfileKeys(appCfg *appconfig.Config) []string {
+	if appCfg.UsesSSOSession() {
+		return legacySSOKeys
+	}
+	return sessionSSOKeys
+}
+
+// removeStaleKeys removes the keys of the profile format not in use from a
+// regenerated profile, and the credential_process generate wrote when it is no
+// longer enabled. A credential_process set by hand is kept.
+func removeStaleKeys(configParser *configparser.ConfigParser, section string, appCfg *appconfig.Config) {
+	removeOptions(configParser, section, staleProfileKeys(appCfg))
+
+	if !appCfg.Generate.CredentialProcess {
+		if value, err := configParser.Get(section, credentialProcessKey); err == nil && strings.HasPrefix(value, credentialProcessCommand+" ") {
+			removeOptions(configParser, section, []string{credentialProcessKey})
+		}
+	}
+}
+
+// mergeValues returns the values to write to an existing section under the merge
+// policy. New sections are always written in full.
+func mergeValues(configParser *configparser.ConfigParser, section string, values map[string]string, policy string) map[string]string {
+	if !configParser.HasSection(section) || policy == appconfig.MergePolicyOverwrite || policy == "" {
+		return values
+	}
+
+	merged := make(map[string]string, len(values))
+	for key, value := range values {
+		if policy == appconfig.MergePolicyOnlyManagedKeys && managedKeys[key] {
+			merged[key] = value
+			continue
+		}
+		if ok, _ := configParser.HasOption(section, key); !ok {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// removeOptions removes the given keys from a section, ignoring keys which are not set
+func removeOptions(configParser *configparser.ConfigParser, section string, keys []string) {
+	for _, key := range keys {
+		if ok, _ := configParser.HasOption(section, key); ok {
+			_ = configParser.RemoveOption(section, key)
+		}
+	}
+}
+
+// pruneSections removes generated profiles which are not in keep, along with
+// generated sso-session sections no remaining profile references. Sections
+// without the generated marker are never removed. The removed sections are returned.
+func pruneSections(configParser *configparser.ConfigParser, keep map[string]bool) []string {
+	var removed []string
+	for _, section := range configParser.Sections() {
+		if strings.HasPrefix(section, "profile ") && !keep[section] && awsprovider.IsGenerated(configParser, section) {
+			_ = configParser.RemoveSection(section)
+			removed = append(removed, section)
+		}
+	}
+
+	referenced := make(map[string]bool)
+	for _, section := range configParser.Sections() {
+		if session, err := configParser.Get(section, "sso_session"); err == nil {
+			referenced["sso-session "+session] = true
+		}
+	}
+	for _, section := range configParser.Sections() {
+		if strings.HasPrefix(section, "sso-session ") && !keep[section] && !referenced[section] && awsprovider.IsGenerated(configParser, section) {
+			_ = configParser.RemoveSection(section)
+			removed = append(removed, section)
+		}
+	}
+
+	return removed
+}
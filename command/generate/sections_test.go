@@ -0,0 +1,227 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bigkevmcd/go-configparser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+func TestProfileValuesLegacy(t *testing.T) {
+	appCfg := appconfig.Default()
+	appCfg.SSO.StartURL = "https://test.awsapps.com/start"
+	appCfg.SSO.Region = "us-west-2"
+	appCfg.AWS.DefaultRegion = "eu-west-1"
+
+	values := profileValues(testTarget("123456789012", "prod", "Admin"), appCfg, "")
+
+	assert.Equal(t, map[string]string{
+		"sso_account_id": "123456789012",
+		"sso_role_name":  "Admin",
+		"region":         "eu-west-1",
+		"sso_start_url":  "https://test.awsapps.com/start",
+		"sso_region":     "us-west-2",
+		"generated_by":   "aws-sso-config",
+	}, values)
+	assert.Equal(t, []string{"sso_session"}, staleProfileKeys(appCfg))
+}
+
+func TestProfileValuesSSOSession(t *testing.T) {
+	appCfg := appconfig.Default()
+	appCfg.SSO.StartURL = "https://test.awsapps.com/start"
+	appCfg.SSO.Region = "us-west-2"
+	appCfg.SSO.SessionName = "my-org"
+	appCfg.AWS.DefaultRegion = "eu-west-1"
+	appCfg.Generate.ProfileFormat = appconfig.ProfileFormatSSOSession
+
+	values := profileValues(testTarget("123456789012", "prod", "Admin"), appCfg, "")
+
+	assert.Equal(t, map[string]string{
+		"sso_account_id": "123456789012",
+		"sso_role_name":  "Admin",
+		"region":         "eu-west-1",
+		"sso_session":    "my-org",
+		"generated_by":   "aws-sso-config",
+	}, values)
+	assert.Equal(t, []string{"sso_start_url", "sso_region"}, staleProfileKeys(appCfg))
+
+	assert.Equal(t, "sso-session my-org", sessionSectionName(appCfg))
+	assert.Equal(t, map[string]string{
+		"sso_start_url":           "https://test.awsapps.com/start",
+		"sso_region":              "us-west-2",
+		"sso_registration_scopes": "sso:account:access",
+		"generated_by":            "aws-sso-config",
+	}, sessionValues(appCfg))
+}
+
+func TestProfileValuesCredentialProcess(t *testing.T) {
+	appCfg := appconfig.Default()
+	appCfg.Generate.CredentialProcess = true
+
+	target := testTarget("123456789012", "prod", "Admin")
+	target.ProfileName = "prod"
+	values := profileValues(target, appCfg, "")
+	assert.Equal(t, "aws-sso-config credential-process --profile prod", values["credential_process"])
+
+	// The app config given with -config is used by credential_process too
+	values = profileValues(target, appCfg, "/home/me/sso config.toml")
+	assert.Equal(t, `aws-sso-config credential-process --config "/home/me/sso config.toml" --profile prod`, values["credential_process"])
+}
+
+func TestCredentialProcess(t *testing.T) {
+	tests := []struct {
+		profile  string
+		expected string
+	}{
+		{"prod-admin", "aws-sso-config credential-process --profile prod-admin"},
+		{"team.prod_Admin@1", "aws-sso-config credential-process --profile team.prod_Admin@1"},
+		{"Production Account", `aws-sso-config credential-process --profile "Production Account"`},
+		{`say "$HOME"`, `aws-sso-config credential-process --profile "say \"\$HOME\""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			assert.Equal(t, tt.expected, credentialProcess(tt.profile, ""))
+		})
+	}
+}
+
+func TestRemoveStaleKeys(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(configFile, []byte(`[profile generated]
+sso_session = my-org
+credential_process = aws-sso-config credential-process --profile generated
+
+[profile custom]
+credential_process = my-credential-helper
+`), 0600)
+	require.NoError(t, err)
+
+	configParser, err := configparser.NewConfigParserFromFile(configFile)
+	require.NoError(t, err)
+
+	// The credential_process is kept while it is enabled
+	appCfg := appconfig.Default()
+	appCfg.Generate.CredentialProcess = true
+	removeStaleKeys(configParser, "profile generated", appCfg)
+	options, err := configParser.Options("profile generated")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"credential_process"}, options)
+
+	// And only the one generate wrote is removed once it is disabled
+	appCfg.Generate.CredentialProcess = false
+	removeStaleKeys(configParser, "profile generated", appCfg)
+	removeStaleKeys(configParser, "profile custom", appCfg)
+	options, err = configParser.Options("profile generated")
+	require.NoError(t, err)
+	assert.Empty(t, options)
+	options, err = configParser.Options("profile custom")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"credential_process"}, options)
+}
+
+func TestRemoveOptions(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(configFile, []byte("[profile prod]\nsso_start_url = https://test\nregion = us-east-1\n"), 0600)
+	require.NoError(t, err)
+
+	configParser, err := configparser.NewConfigParserFromFile(configFile)
+	require.NoError(t, err)
+
+	removeOptions(configParser, "profile prod", []string{"sso_start_url", "sso_region"})
+
+	options, err := configParser.Options("profile prod")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"region"}, options)
+}
+
+func TestPruneSections(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(configFile, []byte(`[default]
+region = us-east-1
+
+[profile current]
+sso_session = old
+generated_by = aws-sso-config
+
+[profile stale]
+sso_account_id = 111111111111
+generated_by = aws-sso-config
+
+[profile hand-written]
+sso_account_id = 222222222222
+
+[sso-session old]
+generated_by = aws-sso-config
+
+[sso-session unused]
+generated_by = aws-sso-config
+
+[sso-session mine]
+sso_region = us-east-1
+`), 0600)
+	require.NoError(t, err)
+
+	configParser, err := configparser.NewConfigParserFromFile(configFile)
+	require.NoError(t, err)
+
+	removed := pruneSections(configParser, map[string]bool{"profile current": true})
+
+	assert.Equal(t, []string{"profile stale", "sso-session unused"}, removed)
+	assert.ElementsMatch(t, []string{
+		"default",
+		"profile current",
+		"profile hand-written",
+		"sso-session old",
+		"sso-session mine",
+	}, configParser.Sections())
+}
+
+func TestMergeValues(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(configFile, []byte(`[profile prod]
+sso_account_id = 111111111111
+sso_role_name = OldRole
+region = eu-west-1
+`), 0600)
+	require.NoError(t, err)
+
+	configParser, err := configparser.NewConfigParserFromFile(configFile)
+	require.NoError(t, err)
+
+	values := map[string]string{
+		"sso_account_id": "111111111111",
+		"sso_role_name":  "NewRole",
+		"region":         "us-east-1",
+		"sso_session":    "my-org",
+	}
+
+	tests := []struct {
+		policy   string
+		section  string
+		expected map[string]string
+	}{
+		{appconfig.MergePolicyOverwrite, "profile prod", values},
+		{appconfig.MergePolicyPreserveExisting, "profile prod", map[string]string{
+			"sso_session": "my-org",
+		}},
+		{appconfig.MergePolicyOnlyManagedKeys, "profile prod", map[string]string{
+			"sso_account_id": "111111111111",
+			"sso_role_name":  "NewRole",
+			"sso_session":    "my-org",
+		}},
+		// New sections are always written in full
+		{appconfig.MergePolicyPreserveExisting, "profile new", values},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy+"/"+tt.section, func(t *testing.T) {
+			assert.Equal(t, tt.expected, mergeValues(configParser, tt.section, values, tt.policy))
+		})
+	}
+}
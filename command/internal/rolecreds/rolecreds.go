@@ -0,0 +1,64 @@
+package rolecreds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
+	"github.com/pkg/browser"
+
+	awsprovider "github.com/blairham/aws-sso-config/providers/aws"
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// Source gets credentials for the roles of SSO profiles, for the commands which
+// pass them on to other programs. Tests replace its dependencies.
+type Source struct {
+	SSOClientFactory func(aws.Config) awsprovider.RoleCredentialsClient
+	NewAuthenticator func(ui cli.Ui, cfg aws.Config) awsprovider.Authenticator
+	ConfigLoader     func() (aws.Config, error)
+}
+
+// New returns a Source using AWS SSO, logging in with the browser when needed
+func New() Source {
+	return Source{
+		SSOClientFactory: func(cfg aws.Config) awsprovider.RoleCredentialsClient {
+			return sso.NewFromConfig(cfg)
+		},
+		NewAuthenticator: func(ui cli.Ui, cfg aws.Config) awsprovider.Authenticator {
+			return awsprovider.NewAuthenticator(ui, ssooidc.NewFromConfig(cfg), browser.OpenURL)
+		},
+		ConfigLoader: awsprovider.LoadDefaultConfig,
+	}
+}
+
+// Get loads the named profile of the AWS config file and returns credentials for
+// its role, logging in to AWS SSO through ui when there is no valid token. Ctrl-C
+// stops the login.
+func (s Source) Get(ui cli.Ui, appCfg *appconfig.Config, name string) (*awsprovider.SSOProfile, *awsprovider.RoleCredentials, error) {
+	profile, err := awsprovider.LoadSSOProfile(appCfg.ConfigFile(), name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	cfg, err := s.ConfigLoader()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	// Role credentials are vended in the SSO region
+	cfg.Region = profile.SSORegion
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	creds, err := awsprovider.ProfileCredentials(ctx, ui, s.NewAuthenticator(ui, cfg), s.SSOClientFactory(cfg), appCfg, profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return profile, creds, nil
+}
@@ -0,0 +1,41 @@
+package login
+
+const synopsis = "Log in to AWS SSO"
+const help = `
+Usage: aws-sso-config login [options]
+
+  This command logs in to AWS SSO and caches the access token in the
+  AWS CLI SSO cache, without changing the AWS config file. The AWS CLI,
+  SDKs and the other commands of this tool then use the cached token
+  until it expires.
+
+  A valid cached token is reused and an expired one is refreshed when
+  possible; only then are you asked to log in. The time the token
+  expires is shown.
+
+Options:
+
+  -sso-session=<name>  Log in to the [sso-session <name>] section of
+                       the AWS config file instead of the configured
+                       start URL.
+
+  -force               Log in again even if a valid token is cached.
+
+  -no-browser          Print the login URL and code to enter there
+                       instead of opening a browser. Also enabled by
+                       login.headless.
+
+  -config=<path>       Path to configuration file. If not specified,
+                       uses environment variables and defaults.
+
+Examples:
+
+  # Log in to the configured start URL
+  aws-sso-config login
+
+  # Log in to a session from the AWS config file
+  aws-sso-config login -sso-session=my-sso
+
+  # Log in from a machine without a browser
+  aws-sso-config login -no-browser
+`
@@ -0,0 +1,146 @@
+package login
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
+	"github.com/pkg/browser"
+
+	"github.com/blairham/aws-sso-config/command/flags"
+	awsprovider "github.com/blairham/aws-sso-config/providers/aws"
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	help  string
+
+	ssoSession string
+	force      bool
+	noBrowser  bool
+	configFile string
+
+	// Dependencies for testing
+	configLoader     func() (aws.Config, error)
+	newAuthenticator func(ui cli.Ui, cfg aws.Config, force bool) awsprovider.Authenticator
+}
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.Init()
+	c.configLoader = awsprovider.LoadDefaultConfig
+	c.newAuthenticator = defaultAuthenticator
+	return c
+}
+
+// NewWithDependencies creates a new command with injected dependencies for testing
+func NewWithDependencies(
+	ui cli.Ui,
+	newAuthenticator func(ui cli.Ui, cfg aws.Config, force bool) awsprovider.Authenticator,
+	configLoader func() (aws.Config, error),
+) *cmd {
+	c := &cmd{UI: ui}
+	c.Init()
+	c.newAuthenticator = newAuthenticator
+	c.configLoader = configLoader
+	return c
+}
+
+// defaultAuthenticator returns the AWS SSO authenticator. Forced logins skip the
+// cached and refreshed tokens.
+func defaultAuthenticator(ui cli.Ui, cfg aws.Config, force bool) awsprovider.Authenticator {
+	client := ssooidc.NewFromConfig(cfg)
+	if force {
+		return &awsprovider.LoginAuthenticator{UI: ui, Client: client, BrowserOpener: browser.OpenURL}
+	}
+	return awsprovider.NewAuthenticator(ui, client, browser.OpenURL)
+}
+
+func (c *cmd) Init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.StringVar(&c.ssoSession, "sso-session", "", "Log in to this sso-session of the AWS config file.")
+	c.flags.BoolVar(&c.force, "force", false, "Log in again even if a valid token is cached.")
+	c.flags.BoolVar(&c.noBrowser, "no-browser", false, "Print the login URL and code instead of opening a browser.")
+	c.flags.StringVar(&c.configFile, "config", "", "Path to configuration file.")
+
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	appCfg, err := c.loadConfig()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Configuration error: %v", err))
+		return 1
+	}
+
+	cfg, err := c.configLoader()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error loading AWS configuration: %v", err))
+		return 1
+	}
+	// The OIDC endpoints are in the SSO region
+	cfg.Region = appCfg.SSORegion()
+
+	// Ctrl-C stops waiting for the login
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	token, err := c.newAuthenticator(c.UI, cfg, c.force).Authenticate(ctx, appCfg)
+	if err != nil {
+		c.UI.Error(awsprovider.LoginErrorMessage(err))
+		return 1
+	}
+
+	c.UI.Output(loginMessage(appCfg, awsprovider.ToString(token), time.Now()))
+	return 0
+}
+
+// loadConfig loads the configuration, applying the command-line flags
+func (c *cmd) loadConfig() (*appconfig.Config, error) {
+	appCfg, err := appconfig.LoadOrDefault(c.configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.noBrowser {
+		appCfg.Login.Headless = true
+	}
+	if c.ssoSession != "" {
+		if err := awsprovider.UseSSOSession(appCfg, c.ssoSession); err != nil {
+			return nil, err
+		}
+	}
+	return appCfg, appCfg.Validate()
+}
+
+// loginMessage returns the message shown after logging in, with the expiry of the
+// cached token
+func loginMessage(appCfg *appconfig.Config, token string, now time.Time) string {
+	message := fmt.Sprintf("Logged in to %s", appCfg.SSOStartURL())
+	entry := awsprovider.CachedToken(appCfg)
+	if entry == nil || entry.AccessToken != token {
+		return message
+	}
+	return fmt.Sprintf("%s, the token expires at %s (in %s)",
+		message, entry.ExpiresAt.Local().Format(time.RFC1123), entry.ExpiresAt.Sub(now).Round(time.Minute))
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
@@ -0,0 +1,204 @@
+package login
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/go-homedir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blairham/aws-sso-config/command/config/set"
+	awsprovider "github.com/blairham/aws-sso-config/providers/aws"
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// MockAuthenticator records the configuration it authenticates
+type MockAuthenticator struct {
+	token  string
+	err    error
+	appCfg *appconfig.Config
+}
+
+func (m *MockAuthenticator) Authenticate(_ context.Context, appCfg *appconfig.Config) (*string, error) {
+	m.appCfg = appCfg
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &m.token, nil
+}
+
+func mockConfigLoader() (aws.Config, error) {
+	return aws.Config{}, nil
+}
+
+// testConfigFile writes an app config file using an AWS config file and SSO
+// cache in a temporary directory
+func testConfigFile(t *testing.T, awsConfig string) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("AWS_SSO_CACHE_PATH", filepath.Join(dir, "cache"))
+
+	awsConfigFile := filepath.Join(dir, "aws-config")
+	require.NoError(t, os.WriteFile(awsConfigFile, []byte(awsConfig), 0600))
+
+	appConfigFile := filepath.Join(dir, "app-config.toml")
+	require.NoError(t, os.WriteFile(appConfigFile, []byte(`[sso]
+start_url = "https://test.awsapps.com/start"
+region = "us-west-2"
+
+[aws]
+config_file = "`+awsConfigFile+`"
+`), 0600))
+	return appConfigFile
+}
+
+func TestInit(t *testing.T) {
+	c := New(cli.NewMockUi())
+
+	assert.NotNil(t, c.flags)
+	assert.NotEmpty(t, c.help)
+	assert.Equal(t, synopsis, c.Synopsis())
+	assert.Contains(t, c.Help(), "Usage: aws-sso-config login")
+}
+
+func TestRun(t *testing.T) {
+	configFile := testConfigFile(t, "")
+	authenticator := &MockAuthenticator{token: "access-token"}
+	var forced bool
+
+	ui := cli.NewMockUi()
+	c := NewWithDependencies(ui, func(_ cli.Ui, _ aws.Config, force bool) awsprovider.Authenticator {
+		forced = force
+		return authenticator
+	}, mockConfigLoader)
+
+	assert.Equal(t, 0, c.Run([]string{"-config=" + configFile, "-force", "-no-browser"}))
+	assert.True(t, forced)
+	assert.True(t, authenticator.appCfg.Login.Headless)
+	assert.Equal(t, "https://test.awsapps.com/start", authenticator.appCfg.SSOStartURL())
+	assert.Contains(t, ui.OutputWriter.String(), "Logged in to https://test.awsapps.com/start")
+}
+
+func TestRunWithConfigSetValues(t *testing.T) {
+	homedir.DisableCache = true
+	t.Cleanup(func() { homedir.DisableCache = false })
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AWS_SSO_CACHE_PATH", t.TempDir())
+
+	// Values saved with config set are used when no -config is given
+	for _, kv := range [][2]string{
+		{"sso.start_url", "https://test.awsapps.com/start"},
+		{"login.flow", appconfig.LoginFlowPKCE},
+		{"login.headless", "true"},
+	} {
+		require.Equal(t, 0, set.New(cli.NewMockUi()).Run(kv[:]), kv[0])
+	}
+
+	authenticator := &MockAuthenticator{token: "access-token"}
+	c := NewWithDependencies(cli.NewMockUi(), func(cli.Ui, aws.Config, bool) awsprovider.Authenticator {
+		return authenticator
+	}, mockConfigLoader)
+
+	assert.Equal(t, 0, c.Run([]string{}))
+	assert.Equal(t, "https://test.awsapps.com/start", authenticator.appCfg.SSOStartURL())
+	assert.Equal(t, appconfig.LoginFlowPKCE, authenticator.appCfg.Login.Flow)
+	assert.True(t, authenticator.appCfg.Login.Headless)
+}
+
+func TestRunSSOSession(t *testing.T) {
+	configFile := testConfigFile(t, `[sso-session my-sso]
+sso_start_url = https://other.awsapps.com/start
+sso_region = eu-west-1
+`)
+	authenticator := &MockAuthenticator{token: "access-token"}
+	var region string
+	newAuthenticator := func(_ cli.Ui, cfg aws.Config, _ bool) awsprovider.Authenticator {
+		region = cfg.Region
+		return authenticator
+	}
+
+	ui := cli.NewMockUi()
+	c := NewWithDependencies(ui, newAuthenticator, mockConfigLoader)
+	assert.Equal(t, 0, c.Run([]string{"-config=" + configFile, "-sso-session=my-sso"}))
+	assert.Equal(t, "https://other.awsapps.com/start", authenticator.appCfg.SSOStartURL())
+	assert.Equal(t, "eu-west-1", authenticator.appCfg.SSORegion())
+	assert.Equal(t, "eu-west-1", region)
+	assert.Equal(t, "my-sso", authenticator.appCfg.SSO.SessionName)
+
+	ui = cli.NewMockUi()
+	c = NewWithDependencies(ui, newAuthenticator, mockConfigLoader)
+	assert.Equal(t, 1, c.Run([]string{"-config=" + configFile, "-sso-session=missing"}))
+	assert.Contains(t, ui.ErrorWriter.String(), `sso-session "missing" not found`)
+}
+
+func TestRunErrors(t *testing.T) {
+	configFile := testConfigFile(t, "")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"canceled", awsprovider.ErrLoginCanceled, "Login canceled"},
+		{"expired", awsprovider.ErrAuthorizationExpired, "Login failed: authorization timed out"},
+		{"other", errors.New("failed to register client"), "Error logging in to AWS SSO: failed to register client"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := NewWithDependencies(ui, func(cli.Ui, aws.Config, bool) awsprovider.Authenticator {
+				return &MockAuthenticator{err: tt.err}
+			}, mockConfigLoader)
+
+			assert.Equal(t, 1, c.Run([]string{"-config=" + configFile}))
+			assert.Contains(t, ui.ErrorWriter.String(), tt.expected)
+			assert.Empty(t, ui.OutputWriter.String())
+		})
+	}
+}
+
+func TestRunConfigLoaderError(t *testing.T) {
+	configFile := testConfigFile(t, "")
+
+	ui := cli.NewMockUi()
+	c := NewWithDependencies(ui, func(cli.Ui, aws.Config, bool) awsprovider.Authenticator {
+		t.Fail() // Should not be called
+		return nil
+	}, func() (aws.Config, error) {
+		return aws.Config{}, fmt.Errorf("no credentials")
+	})
+
+	assert.Equal(t, 1, c.Run([]string{"-config=" + configFile}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Error loading AWS configuration: no credentials")
+}
+
+func TestLoginMessage(t *testing.T) {
+	configFile := testConfigFile(t, "")
+	appCfg, err := appconfig.Load(configFile)
+	require.NoError(t, err)
+
+	// Without a cached token the expiry is unknown
+	assert.Equal(t, "Logged in to https://test.awsapps.com/start", loginMessage(appCfg, "access-token", time.Now()))
+
+	now := time.Now()
+	expiresAt := now.Add(8 * time.Hour).Truncate(time.Second)
+	cacheDir := os.Getenv("AWS_SSO_CACHE_PATH")
+	require.NoError(t, os.MkdirAll(cacheDir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "token.json"), []byte(fmt.Sprintf(
+		`{"accessToken":"access-token","expiresAt":%q,"startUrl":"https://test.awsapps.com/start"}`,
+		expiresAt.UTC().Format(time.RFC3339),
+	)), 0600))
+
+	message := loginMessage(appCfg, "access-token", now)
+	assert.Contains(t, message, "Logged in to https://test.awsapps.com/start, the token expires at "+expiresAt.Local().Format(time.RFC1123))
+	assert.Contains(t, message, "(in 8h0m0s)")
+}
@@ -0,0 +1,28 @@
+package logout
+
+const synopsis = "Log out of AWS SSO"
+const help = `
+Usage: aws-sso-config logout [options]
+
+  This command signs the cached AWS SSO access token out of the portal
+  and removes it from the AWS CLI SSO cache, together with the cached
+  client registration. The AWS config file is not changed. The next
+  login asks you to log in again.
+
+Options:
+
+  -sso-session=<name>  Log out of the [sso-session <name>] section of
+                       the AWS config file instead of the configured
+                       start URL.
+
+  -config=<path>       Path to configuration file. If not specified,
+                       uses environment variables and defaults.
+
+Examples:
+
+  # Log out of the configured start URL
+  aws-sso-config logout
+
+  # Log out of a session from the AWS config file
+  aws-sso-config logout -sso-session=my-sso
+`
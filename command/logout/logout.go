@@ -0,0 +1,115 @@
+package logout
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/mitchellh/cli"
+
+	"github.com/blairham/aws-sso-config/command/flags"
+	awsprovider "github.com/blairham/aws-sso-config/providers/aws"
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	help  string
+
+	ssoSession string
+	configFile string
+
+	// Dependencies for testing
+	ssoClientFactory func(aws.Config) awsprovider.SSOLogoutClient
+	configLoader     func() (aws.Config, error)
+}
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.Init()
+	c.ssoClientFactory = func(cfg aws.Config) awsprovider.SSOLogoutClient {
+		return sso.NewFromConfig(cfg)
+	}
+	c.configLoader = awsprovider.LoadDefaultConfig
+	return c
+}
+
+// NewWithDependencies creates a new command with injected dependencies for testing
+func NewWithDependencies(ui cli.Ui, ssoClientFactory func(aws.Config) awsprovider.SSOLogoutClient, configLoader func() (aws.Config, error)) *cmd {
+	c := &cmd{UI: ui}
+	c.Init()
+	c.ssoClientFactory = ssoClientFactory
+	c.configLoader = configLoader
+	return c
+}
+
+func (c *cmd) Init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.StringVar(&c.ssoSession, "sso-session", "", "Log out of this sso-session of the AWS config file.")
+	c.flags.StringVar(&c.configFile, "config", "", "Path to configuration file.")
+
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	appCfg, err := c.loadConfig()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Configuration error: %v", err))
+		return 1
+	}
+
+	cfg, err := c.configLoader()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error loading AWS configuration: %v", err))
+		return 1
+	}
+	// The portal is signed out of in the SSO region
+	cfg.Region = appCfg.SSORegion()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	removed, err := awsprovider.Logout(ctx, c.UI, c.ssoClientFactory(cfg), appCfg)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error logging out of AWS SSO: %v", err))
+		return 1
+	}
+
+	if removed {
+		c.UI.Output(fmt.Sprintf("Logged out of %s", appCfg.SSOStartURL()))
+	} else {
+		c.UI.Output(fmt.Sprintf("Not logged in to %s", appCfg.SSOStartURL()))
+	}
+	return 0
+}
+
+// loadConfig loads the configuration, applying the command-line flags
+func (c *cmd) loadConfig() (*appconfig.Config, error) {
+	appCfg, err := appconfig.LoadOrDefault(c.configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.ssoSession != "" {
+		if err := awsprovider.UseSSOSession(appCfg, c.ssoSession); err != nil {
+			return nil, err
+		}
+	}
+	return appCfg, appCfg.Validate()
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
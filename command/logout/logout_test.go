@@ -0,0 +1,119 @@
+package logout
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	awsprovider "github.com/blairham/aws-sso-config/providers/aws"
+)
+
+// Mock implementation of SSOLogoutClient interface for testing
+type MockSSOLogoutClient struct {
+	mock.Mock
+}
+
+func (m *MockSSOLogoutClient) Logout(ctx context.Context, params *sso.LogoutInput, optFns ...func(*sso.Options)) (*sso.LogoutOutput, error) {
+	args := m.Called(ctx, params)
+	result := args.Get(0)
+	if result == nil {
+		return nil, args.Error(1)
+	}
+	return result.(*sso.LogoutOutput), args.Error(1)
+}
+
+func mockConfigLoader() (aws.Config, error) {
+	return aws.Config{}, nil
+}
+
+// testConfigFile writes an app config file and a cached token for it to a
+// temporary directory, returning the config file and the token cache file
+func testConfigFile(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+	t.Setenv("AWS_SSO_CACHE_PATH", cacheDir)
+
+	appConfigFile := filepath.Join(dir, "app-config.toml")
+	require.NoError(t, os.WriteFile(appConfigFile, []byte(`[sso]
+start_url = "https://test.awsapps.com/start"
+region = "us-west-2"
+`), 0600))
+
+	require.NoError(t, os.MkdirAll(cacheDir, 0700))
+	tokenFile := filepath.Join(cacheDir, "token.json")
+	require.NoError(t, os.WriteFile(tokenFile, []byte(fmt.Sprintf(
+		`{"accessToken":"access-token","expiresAt":%q,"startUrl":"https://test.awsapps.com/start"}`,
+		time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	)), 0600))
+	return appConfigFile, tokenFile
+}
+
+func TestInit(t *testing.T) {
+	c := New(cli.NewMockUi())
+
+	assert.NotNil(t, c.flags)
+	assert.NotEmpty(t, c.help)
+	assert.Equal(t, synopsis, c.Synopsis())
+	assert.Contains(t, c.Help(), "Usage: aws-sso-config logout")
+}
+
+func TestRun(t *testing.T) {
+	configFile, tokenFile := testConfigFile(t)
+
+	mockClient := new(MockSSOLogoutClient)
+	mockClient.On("Logout", mock.Anything, mock.MatchedBy(func(input *sso.LogoutInput) bool {
+		return aws.ToString(input.AccessToken) == "access-token"
+	})).Return(&sso.LogoutOutput{}, nil).Once()
+	var region string
+	clientFactory := func(cfg aws.Config) awsprovider.SSOLogoutClient {
+		region = cfg.Region
+		return mockClient
+	}
+
+	ui := cli.NewMockUi()
+	c := NewWithDependencies(ui, clientFactory, mockConfigLoader)
+	assert.Equal(t, 0, c.Run([]string{"-config=" + configFile}))
+	assert.Contains(t, ui.OutputWriter.String(), "Logged out of https://test.awsapps.com/start")
+	assert.NoFileExists(t, tokenFile)
+	assert.Equal(t, "us-west-2", region)
+	mockClient.AssertExpectations(t)
+
+	// There is nothing left to log out of
+	ui = cli.NewMockUi()
+	c = NewWithDependencies(ui, clientFactory, mockConfigLoader)
+	assert.Equal(t, 0, c.Run([]string{"-config=" + configFile}))
+	assert.Contains(t, ui.OutputWriter.String(), "Not logged in to https://test.awsapps.com/start")
+}
+
+func TestRunErrors(t *testing.T) {
+	configFile, tokenFile := testConfigFile(t)
+	clientFactory := func(aws.Config) awsprovider.SSOLogoutClient {
+		t.Fail() // Should not be called
+		return nil
+	}
+
+	ui := cli.NewMockUi()
+	c := NewWithDependencies(ui, clientFactory, func() (aws.Config, error) {
+		return aws.Config{}, fmt.Errorf("no credentials")
+	})
+	assert.Equal(t, 1, c.Run([]string{"-config=" + configFile}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Error loading AWS configuration: no credentials")
+
+	ui = cli.NewMockUi()
+	c = NewWithDependencies(ui, clientFactory, mockConfigLoader)
+	assert.Equal(t, 1, c.Run([]string{"-config=" + configFile, "-sso-session=missing"}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Configuration error")
+
+	assert.FileExists(t, tokenFile)
+}
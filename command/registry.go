@@ -7,7 +7,13 @@ import (
 
 	"github.com/blairham/aws-sso-config/command/cli"
 	"github.com/blairham/aws-sso-config/command/config"
+	"github.com/blairham/aws-sso-config/command/credentialprocess"
+	"github.com/blairham/aws-sso-config/command/credentials"
+	"github.com/blairham/aws-sso-config/command/export"
 	"github.com/blairham/aws-sso-config/command/generate"
+	"github.com/blairham/aws-sso-config/command/login"
+	"github.com/blairham/aws-sso-config/command/logout"
+	"github.com/blairham/aws-sso-config/command/run"
 )
 
 // factory is a function that returns a new instance of a CLI-sub command.
@@ -24,7 +30,13 @@ func RegisteredCommands(ui cli.UI) map[string]mcli.CommandFactory {
 	registerCommands(ui, registry,
 		// Add new commands here
 		entry{"config", func(ui cli.UI) (cli.Command, error) { return config.New(ui), nil }},
+		entry{"credential-process", func(ui cli.UI) (cli.Command, error) { return credentialprocess.New(ui), nil }},
+		entry{"credentials", func(ui cli.UI) (cli.Command, error) { return credentials.New(ui), nil }},
+		entry{"export", func(ui cli.UI) (cli.Command, error) { return export.New(ui), nil }},
 		entry{"generate", func(ui cli.UI) (cli.Command, error) { return generate.New(ui), nil }},
+		entry{"login", func(ui cli.UI) (cli.Command, error) { return login.New(ui), nil }},
+		entry{"logout", func(ui cli.UI) (cli.Command, error) { return logout.New(ui), nil }},
+		entry{"run", func(ui cli.UI) (cli.Command, error) { return run.New(ui), nil }},
 	)
 
 	return registry
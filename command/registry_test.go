@@ -90,7 +90,13 @@ func TestRegisteredCommands(t *testing.T) {
 	// Test that all expected commands are registered
 	expectedCommands := []string{
 		"config",
+		"credential-process",
+		"credentials",
+		"export",
 		"generate",
+		"login",
+		"logout",
+		"run",
 	}
 
 	for _, expectedCmd := range expectedCommands {
@@ -200,7 +206,13 @@ func TestSpecificCommands(t *testing.T) {
 		expectedType string
 	}{
 		{"config", "config"},
+		{"credential-process", "credential-process"},
+		{"credentials", "credentials"},
+		{"export", "export"},
 		{"generate", "generate"},
+		{"login", "login"},
+		{"logout", "logout"},
+		{"run", "run"},
 	}
 
 	for _, tt := range tests {
@@ -217,8 +229,20 @@ func TestSpecificCommands(t *testing.T) {
 			switch tt.name {
 			case "config":
 				assert.Contains(t, synopsis, "configuration")
+			case "credential-process":
+				assert.Contains(t, synopsis, "credential_process")
+			case "credentials":
+				assert.Contains(t, synopsis, "credentials file")
+			case "export":
+				assert.Contains(t, synopsis, "environment variables")
 			case "generate":
 				assert.Contains(t, synopsis, "Generate")
+			case "login":
+				assert.Contains(t, synopsis, "Log in")
+			case "logout":
+				assert.Contains(t, synopsis, "Log out")
+			case "run":
+				assert.Contains(t, synopsis, "Run")
 			}
 		})
 	}
@@ -0,0 +1,37 @@
+package run
+
+const synopsis = "Run a command with AWS credentials"
+const help = `
+Usage: aws-sso-config run [options] <command> [args...]
+
+  This command runs a command with temporary credentials for the role
+  of an SSO profile of the AWS config file. The credentials are passed
+  to the command in AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+  AWS_SESSION_TOKEN, their expiry in AWS_CREDENTIAL_EXPIRATION, and
+  the profile's region in AWS_REGION.
+
+  Without -profile, the profile is AWS_PROFILE when it is set, the
+  profile named after the git repository containing the current
+  directory when its account matches the repository's terragrunt.hcl,
+  and otherwise "default".
+
+  You are asked to log in to AWS SSO when there is no valid cached
+  token. Input, SIGTERM and SIGHUP are passed to the command, which
+  gets Ctrl-C from the terminal, and this command exits with the
+  command's exit status.
+
+Options:
+
+  -profile=<name>   Profile of the AWS config file to get credentials for.
+
+  -config=<path>    Path to configuration file. If not specified,
+                    uses environment variables and defaults.
+
+Examples:
+
+  # List buckets with the default profile
+  aws-sso-config run aws s3 ls
+
+  # Plan with the credentials of the prod profile
+  aws-sso-config run -profile=prod terraform plan
+`
@@ -0,0 +1,231 @@
+package run
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/mitchellh/cli"
+
+	commandcli "github.com/blairham/aws-sso-config/command/cli"
+	"github.com/blairham/aws-sso-config/command/flags"
+	"github.com/blairham/aws-sso-config/command/internal/rolecreds"
+	awsprovider "github.com/blairham/aws-sso-config/providers/aws"
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// Exit statuses used by shells when a command cannot be run
+const (
+	exitNotExecutable = 126
+	exitNotFound      = 127
+	exitSignalOffset  = 128
+)
+
+// forwardedSignals are passed on to the command
+var forwardedSignals = []os.Signal{syscall.SIGTERM, syscall.SIGHUP}
+
+// terminalSignals are sent by the terminal to the whole process group, so the
+// command already gets them and they are only caught to keep waiting for it
+var terminalSignals = []os.Signal{os.Interrupt, syscall.SIGQUIT}
+
+// credentialVars are the variables removed from the command's environment so that
+// the role credentials are not mixed with other credentials or profiles
+var credentialVars = []string{
+	"AWS_PROFILE",
+	"AWS_DEFAULT_PROFILE",
+	"AWS_ACCESS_KEY_ID",
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN",
+	"AWS_SECURITY_TOKEN",
+	"AWS_CREDENTIAL_EXPIRATION",
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	help  string
+
+	profile    string
+	configFile string
+
+	// Dependencies for testing
+	credentials rolecreds.Source
+	stdin       io.Reader
+	stdout      io.Writer
+	stderr      io.Writer
+}
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui, credentials: rolecreds.New()}
+	c.Init()
+	c.stdin, c.stdout, c.stderr = os.Stdin, os.Stdout, os.Stderr
+	return c
+}
+
+// NewWithDependencies creates a new command with injected dependencies for testing
+func NewWithDependencies(
+	ui cli.Ui,
+	ssoClientFactory func(aws.Config) awsprovider.RoleCredentialsClient,
+	newAuthenticator func(ui cli.Ui, cfg aws.Config) awsprovider.Authenticator,
+	configLoader func() (aws.Config, error),
+	stdin io.Reader,
+	stdout, stderr io.Writer,
+) *cmd {
+	c := &cmd{UI: ui, credentials: rolecreds.Source{
+		SSOClientFactory: ssoClientFactory,
+		NewAuthenticator: newAuthenticator,
+		ConfigLoader:     configLoader,
+	}}
+	c.Init()
+	c.stdin, c.stdout, c.stderr = stdin, stdout, stderr
+	return c
+}
+
+func (c *cmd) Init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.StringVar(&c.profile, "profile", "", "Profile of the AWS config file to get credentials for.")
+	c.flags.StringVar(&c.configFile, "config", "", "Path to configuration file.")
+
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+	command := c.flags.Args()
+	if len(command) == 0 {
+		c.UI.Error("Usage: aws-sso-config run [options] <command> [args...]")
+		return 1
+	}
+
+	appCfg, err := appconfig.LoadOrDefault(c.configFile)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Configuration error: %v", err))
+		return 1
+	}
+
+	name, err := c.profileName()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	// Login progress goes to stderr so that the command's output can be piped
+	profile, creds, err := c.credentials.Get(&commandcli.StderrUI{Ui: c.UI}, appCfg, name)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error getting credentials for profile %s: %v", name, err))
+		return 1
+	}
+
+	return c.runCommand(command, commandEnvironment(os.Environ(), creds.Environment(profile.Region)))
+}
+
+// profileName returns the profile named by -profile, or the profile for the
+// current directory
+func (c *cmd) profileName() (string, error) {
+	if c.profile != "" {
+		return c.profile, nil
+	}
+	name, err := awsprovider.GetProfile()
+	if err != nil {
+		return "", fmt.Errorf("error finding the profile: %w", err)
+	}
+	// GetProfile leaves a profile chosen with AWS_PROFILE alone
+	if name == "" {
+		name = os.Getenv(awsprovider.AwsProfile)
+	}
+	return name, nil
+}
+
+// commandEnvironment returns the environment for the command: the environment
+// of this process without any other credentials or profile, and the variables
+func commandEnvironment(environ []string, vars []awsprovider.EnvVar) []string {
+	replaced := make(map[string]bool, len(credentialVars)+len(vars))
+	for _, name := range credentialVars {
+		replaced[name] = true
+	}
+	for _, v := range vars {
+		replaced[v.Name] = true
+	}
+
+	env := make([]string, 0, len(environ)+len(vars))
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if !replaced[name] {
+			env = append(env, entry)
+		}
+	}
+	for _, v := range vars {
+		env = append(env, v.Name+"="+v.Value)
+	}
+	return env
+}
+
+// runCommand runs the command with the environment, passing on signals this
+// process receives, and returns its exit status
+func (c *cmd) runCommand(command []string, env []string) int {
+	child := exec.Command(command[0], command[1:]...) //nolint:gosec // Running the user's command is the point
+	child.Env = env
+	child.Stdin, child.Stdout, child.Stderr = c.stdin, c.stdout, c.stderr
+
+	// Signals are caught before the command starts, so that none stop this process
+	// while it runs. The ignored channel is never read, dropping terminal signals.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	ignored := make(chan os.Signal, 1)
+	signal.Notify(ignored, terminalSignals...)
+	defer func() {
+		signal.Stop(ignored)
+		signal.Stop(signals)
+		close(signals)
+	}()
+
+	if err := child.Start(); err != nil {
+		c.UI.Error(fmt.Sprintf("Error running %s: %v", command[0], err))
+		if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+			return exitNotFound
+		}
+		return exitNotExecutable
+	}
+
+	go func() {
+		for sig := range signals {
+			// The command may already have exited
+			child.Process.Signal(sig)
+		}
+	}()
+
+	return exitStatus(child.Wait())
+}
+
+// exitStatus returns the exit status of a command which ended with err, using
+// the shell convention of 128 plus the signal number for commands killed by a signal
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return 1
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return exitSignalOffset + int(status.Signal())
+	}
+	return exitErr.ExitCode()
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
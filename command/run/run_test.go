@@ -0,0 +1,216 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	awsprovider "github.com/blairham/aws-sso-config/providers/aws"
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// Mock implementation of RoleCredentialsClient interface for testing
+type MockRoleCredentialsClient struct {
+	mock.Mock
+}
+
+func (m *MockRoleCredentialsClient) GetRoleCredentials(
+	ctx context.Context,
+	params *sso.GetRoleCredentialsInput,
+	optFns ...func(*sso.Options),
+) (*sso.GetRoleCredentialsOutput, error) {
+	args := m.Called(ctx, params)
+	result := args.Get(0)
+	if result == nil {
+		return nil, args.Error(1)
+	}
+	return result.(*sso.GetRoleCredentialsOutput), args.Error(1)
+}
+
+// MockAuthenticator returns a fixed token or error
+type MockAuthenticator struct {
+	token string
+	err   error
+}
+
+func (m *MockAuthenticator) Authenticate(context.Context, *appconfig.Config) (*string, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &m.token, nil
+}
+
+// testCommand returns a command using an AWS config file with a prod profile,
+// writing the command's output to the returned buffers
+func testCommand(t *testing.T, authenticator awsprovider.Authenticator) (*cmd, *cli.MockUi, string, *bytes.Buffer) {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	dir := t.TempDir()
+	t.Setenv("AWS_SSO_CACHE_PATH", filepath.Join(dir, "cache"))
+
+	awsConfigFile := filepath.Join(dir, "aws-config")
+	require.NoError(t, os.WriteFile(awsConfigFile, []byte(`[profile prod]
+sso_start_url = https://test.awsapps.com/start
+sso_region = us-west-2
+sso_account_id = 123456789012
+sso_role_name = Admin
+region = eu-central-1
+`), 0600))
+	appConfigFile := filepath.Join(dir, "app-config.toml")
+	require.NoError(t, os.WriteFile(appConfigFile, []byte(`[aws]
+config_file = "`+awsConfigFile+`"
+`), 0600))
+
+	mockClient := new(MockRoleCredentialsClient)
+	mockClient.On("GetRoleCredentials", mock.Anything, mock.MatchedBy(func(input *sso.GetRoleCredentialsInput) bool {
+		return aws.ToString(input.AccountId) == "123456789012" && aws.ToString(input.RoleName) == "Admin"
+	})).Return(&sso.GetRoleCredentialsOutput{
+		RoleCredentials: &types.RoleCredentials{
+			AccessKeyId:     aws.String("ASIAEXAMPLE"),
+			SecretAccessKey: aws.String("secret"),
+			SessionToken:    aws.String("session"),
+			Expiration:      time.Now().Add(time.Hour).UnixMilli(),
+		},
+	}, nil)
+
+	var ssoRegion string
+	ssoClientFactory := func(cfg aws.Config) awsprovider.RoleCredentialsClient {
+		ssoRegion = cfg.Region
+		return mockClient
+	}
+	t.Cleanup(func() {
+		if ssoRegion != "" {
+			assert.Equal(t, "us-west-2", ssoRegion, "Credentials should be requested in the SSO region")
+		}
+	})
+
+	ui := cli.NewMockUi()
+	stdout := &bytes.Buffer{}
+	c := NewWithDependencies(ui, ssoClientFactory,
+		func(cli.Ui, aws.Config) awsprovider.Authenticator { return authenticator },
+		func() (aws.Config, error) { return aws.Config{}, nil },
+		strings.NewReader("from stdin"), stdout, ui.ErrorWriter,
+	)
+	return c, ui, appConfigFile, stdout
+}
+
+func TestInit(t *testing.T) {
+	c := New(cli.NewMockUi())
+
+	assert.NotNil(t, c.flags)
+	assert.NotEmpty(t, c.help)
+	assert.Equal(t, synopsis, c.Synopsis())
+	assert.Contains(t, c.Help(), "Usage: aws-sso-config run")
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "other")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAOTHER")
+	c, _, configFile, stdout := testCommand(t, &MockAuthenticator{token: "access-token"})
+
+	exitCode := c.Run([]string{
+		"-config=" + configFile, "-profile=prod", "sh", "-c",
+		`echo "$AWS_ACCESS_KEY_ID $AWS_SECRET_ACCESS_KEY $AWS_SESSION_TOKEN $AWS_REGION profile=$AWS_PROFILE"; cat`,
+	})
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, "ASIAEXAMPLE secret session eu-central-1 profile=\nfrom stdin", stdout.String())
+}
+
+func TestRunProfileFromEnvironment(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "prod")
+	c, _, configFile, stdout := testCommand(t, &MockAuthenticator{token: "access-token"})
+
+	assert.Equal(t, 0, c.Run([]string{"-config=" + configFile, "sh", "-c", `echo "$AWS_ACCESS_KEY_ID"`}))
+	assert.Equal(t, "ASIAEXAMPLE\n", stdout.String())
+}
+
+func TestRunExitStatus(t *testing.T) {
+	c, _, configFile, _ := testCommand(t, &MockAuthenticator{token: "access-token"})
+	assert.Equal(t, 3, c.Run([]string{"-config=" + configFile, "-profile=prod", "sh", "-c", "exit 3"}))
+
+	c, _, configFile, _ = testCommand(t, &MockAuthenticator{token: "access-token"})
+	assert.Equal(t, 128+9, c.Run([]string{"-config=" + configFile, "-profile=prod", "sh", "-c", "kill -9 $$"}))
+
+	c, ui, configFile, _ := testCommand(t, &MockAuthenticator{token: "access-token"})
+	assert.Equal(t, exitNotFound, c.Run([]string{"-config=" + configFile, "-profile=prod", "aws-sso-config-missing-command"}))
+	assert.Contains(t, ui.ErrorWriter.String(), "Error running aws-sso-config-missing-command")
+}
+
+func TestRunSignals(t *testing.T) {
+	// Ctrl-C reaches the command from the terminal, so it is not sent again
+	c, _, configFile, _ := testCommand(t, &MockAuthenticator{token: "access-token"})
+	assert.Equal(t, 0, c.Run([]string{"-config=" + configFile, "-profile=prod", "sh", "-c",
+		`trap 'exit 1' INT; kill -INT $PPID; sleep 1 & wait`}))
+
+	// Other signals are passed on
+	c, _, configFile, _ = testCommand(t, &MockAuthenticator{token: "access-token"})
+	assert.Equal(t, 3, c.Run([]string{"-config=" + configFile, "-profile=prod", "sh", "-c",
+		`trap 'exit 3' TERM; kill -TERM $PPID; sleep 1 & wait`}))
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		authenticator awsprovider.Authenticator
+		expected      string
+	}{
+		{"no command", []string{"-profile=prod"}, &MockAuthenticator{token: "access-token"}, "Usage: aws-sso-config run"},
+		{"missing profile", []string{"-profile=missing", "true"}, &MockAuthenticator{token: "access-token"}, `profile "missing" not found`},
+		{
+			"login failure", []string{"-profile=prod", "true"}, &MockAuthenticator{err: awsprovider.ErrLoginCanceled},
+			"Error getting credentials for profile prod: login canceled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ui, configFile, stdout := testCommand(t, tt.authenticator)
+			assert.Equal(t, 1, c.Run(append([]string{"-config=" + configFile}, tt.args...)))
+			assert.Contains(t, ui.ErrorWriter.String(), tt.expected)
+			assert.Empty(t, stdout.String())
+		})
+	}
+}
+
+func TestCommandEnvironment(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"AWS_PROFILE=other",
+		"AWS_ACCESS_KEY_ID=AKIAOTHER",
+		"AWS_REGION=us-east-1",
+		"AWS_CONFIG_FILE=/tmp/config",
+	}
+	vars := []awsprovider.EnvVar{
+		{Name: "AWS_ACCESS_KEY_ID", Value: "ASIA"},
+		{Name: "AWS_REGION", Value: "eu-central-1"},
+	}
+
+	assert.Equal(t, []string{
+		"PATH=/usr/bin",
+		"AWS_CONFIG_FILE=/tmp/config",
+		"AWS_ACCESS_KEY_ID=ASIA",
+		"AWS_REGION=eu-central-1",
+	}, commandEnvironment(environ, vars))
+}
+
+func TestExitStatus(t *testing.T) {
+	assert.Equal(t, 0, exitStatus(nil))
+	assert.Equal(t, 1, exitStatus(errors.New("wait failed")))
+}
@@ -0,0 +1,99 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/cli"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// ErrNoToken is returned by an authenticator which has no token to offer, so that
+// the next authenticator in a chain is tried
+var ErrNoToken = errors.New("no SSO token available")
+
+// Authenticator obtains an access token for the configured SSO portal
+type Authenticator interface {
+	Authenticate(ctx context.Context, appCfg *appconfig.Config) (*string, error)
+}
+
+// AuthenticatorChain tries each authenticator in turn and returns the first token.
+// Authenticators returning ErrNoToken are skipped; any other error stops the chain.
+type AuthenticatorChain []Authenticator
+
+// Authenticate returns the token of the first authenticator which has one
+func (c AuthenticatorChain) Authenticate(ctx context.Context, appCfg *appconfig.Config) (*string, error) {
+	for _, authenticator := range c {
+		token, err := authenticator.Authenticate(ctx, appCfg)
+		if errors.Is(err, ErrNoToken) {
+			continue
+		}
+		return token, err
+	}
+	return nil, ErrNoToken
+}
+
+// CacheAuthenticator returns a valid token from the SSO cache
+type CacheAuthenticator struct{}
+
+// Authenticate returns the cached token for the configured portal
+func (CacheAuthenticator) Authenticate(_ context.Context, appCfg *appconfig.Config) (*string, error) {
+	if token := getCurrentToken(appCfg); token != nil {
+		return token, nil
+	}
+	return nil, ErrNoToken
+}
+
+// RefreshAuthenticator exchanges the cached refresh token for a new access token
+type RefreshAuthenticator struct {
+	UI     cli.Ui
+	Client SSOOIDCClient
+}
+
+// Authenticate refreshes the cached token for the configured portal
+func (a *RefreshAuthenticator) Authenticate(ctx context.Context, appCfg *appconfig.Config) (*string, error) {
+	if token := refreshCachedToken(ctx, a.UI, a.Client, appCfg); token != nil {
+		return token, nil
+	}
+	return nil, ErrNoToken
+}
+
+// LoginAuthenticator logs the user in with the configured login flow and caches
+// the new token in the AWS CLI SSO cache
+type LoginAuthenticator struct {
+	UI            cli.Ui
+	Client        SSOOIDCClient
+	BrowserOpener func(string) error
+}
+
+// Authenticate logs the user in interactively
+func (a *LoginAuthenticator) Authenticate(ctx context.Context, appCfg *appconfig.Config) (*string, error) {
+	register, err := registerClient(ctx, a.UI, a.Client, appCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register client: %w", err)
+	}
+
+	token, err := interactiveLogin(ctx, a.UI, a.Client, appCfg, register, a.BrowserOpener)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeCachedToken(appCfg, newCacheEntry(appCfg, register, token, time.Now())); err != nil {
+		a.UI.Warn(fmt.Sprintf("Failed to cache token: %v", err))
+	}
+	return token.AccessToken, nil
+}
+
+// NewAuthenticator returns the authenticator used to log in to AWS SSO: a cached
+// token is used when valid, then an expired one is refreshed, and only then is the
+// user asked to log in
+func NewAuthenticator(ui cli.Ui, client SSOOIDCClient, browserOpener func(string) error) Authenticator {
+	return AuthenticatorChain{
+		CacheAuthenticator{},
+		&RefreshAuthenticator{UI: ui, Client: client},
+		&LoginAuthenticator{UI: ui, Client: client, BrowserOpener: browserOpener},
+	}
+}
@@ -0,0 +1,151 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// authenticatorFunc adapts a function to the Authenticator interface
+type authenticatorFunc func(ctx context.Context, appCfg *appconfig.Config) (*string, error)
+
+func (f authenticatorFunc) Authenticate(ctx context.Context, appCfg *appconfig.Config) (*string, error) {
+	return f(ctx, appCfg)
+}
+
+func staticAuthenticator(token string, err error, calls *int) Authenticator {
+	return authenticatorFunc(func(context.Context, *appconfig.Config) (*string, error) {
+		*calls++
+		if err != nil {
+			return nil, err
+		}
+		return &token, nil
+	})
+}
+
+func TestAuthenticatorChain(t *testing.T) {
+	failure := errors.New("login failed")
+
+	tests := []struct {
+		name          string
+		errs          []error
+		expectedToken string
+		expectedErr   error
+		expectedCalls []int
+	}{
+		{"first step", []error{nil, nil}, "token-0", nil, []int{1, 0}},
+		{"skips steps without a token", []error{ErrNoToken, nil}, "token-1", nil, []int{1, 1}},
+		{"stops at an error", []error{failure, nil}, "", failure, []int{1, 0}},
+		{"no step has a token", []error{ErrNoToken, ErrNoToken}, "", ErrNoToken, []int{1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := make([]int, len(tt.errs))
+			var chain AuthenticatorChain
+			for i, err := range tt.errs {
+				chain = append(chain, staticAuthenticator(fmt.Sprintf("token-%d", i), err, &calls[i]))
+			}
+
+			token, err := chain.Authenticate(context.Background(), appconfig.Default())
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, token)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedToken, *token)
+			}
+			assert.Equal(t, tt.expectedCalls, calls)
+		})
+	}
+}
+
+func TestNewAuthenticatorUsesCachedToken(t *testing.T) {
+	appCfg, _ := testCacheConfig(t)
+	require.NoError(t, writeCachedToken(appCfg, SSOCacheEntry{
+		AccessToken: "cached-token",
+		ExpiresAt:   time.Now().Add(time.Hour),
+		StartURL:    appCfg.SSOStartURL(),
+		Region:      appCfg.SSORegion(),
+	}))
+
+	// A valid cached token needs no calls to the service
+	mockClient := new(MockSSOOIDCClient)
+	authenticator := NewAuthenticator(cli.NewMockUi(), mockClient, func(string) error {
+		t.Fail() // Should not be called
+		return nil
+	})
+
+	token, err := authenticator.Authenticate(context.Background(), appCfg)
+	require.NoError(t, err)
+	assert.Equal(t, "cached-token", *token)
+	mockClient.AssertNotCalled(t, "RegisterClient", mock.Anything, mock.Anything, mock.Anything)
+	mockClient.AssertNotCalled(t, "CreateToken", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestNewAuthenticatorRefreshesBeforeLogin(t *testing.T) {
+	appCfg, _ := testCacheConfig(t)
+	require.NoError(t, writeCachedToken(appCfg, SSOCacheEntry{
+		AccessToken:  "expired-token",
+		ExpiresAt:    time.Now().Add(-time.Hour),
+		StartURL:     appCfg.SSOStartURL(),
+		Region:       appCfg.SSORegion(),
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RefreshToken: "refresh-token",
+	}))
+
+	mockClient := new(MockSSOOIDCClient)
+	mockClient.On("CreateToken", mock.Anything, mock.MatchedBy(func(input *ssooidc.CreateTokenInput) bool {
+		return aws.ToString(input.GrantType) == grantTypeRefreshToken
+	}), mock.Anything).Return(&ssooidc.CreateTokenOutput{
+		AccessToken: aws.String("refreshed-token"),
+		ExpiresIn:   3600,
+	}, nil).Once()
+
+	authenticator := NewAuthenticator(cli.NewMockUi(), mockClient, func(string) error {
+		t.Fail() // Should not be called
+		return nil
+	})
+
+	token, err := authenticator.Authenticate(context.Background(), appCfg)
+	require.NoError(t, err)
+	assert.Equal(t, "refreshed-token", *token)
+	mockClient.AssertExpectations(t)
+	mockClient.AssertNotCalled(t, "RegisterClient", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLoginAuthenticatorError(t *testing.T) {
+	appCfg, _ := testCacheConfig(t)
+	fakeSleep(t)
+
+	mockClient := new(MockSSOOIDCClient)
+	mockClient.On("RegisterClient", mock.Anything, mock.Anything, mock.Anything).Return(&ssooidc.RegisterClientOutput{
+		ClientId:     aws.String("client-id"),
+		ClientSecret: aws.String("client-secret"),
+	}, nil)
+	mockClient.On("StartDeviceAuthorization", mock.Anything, mock.Anything, mock.Anything).Return(testDeviceAuth(), nil)
+	mockClient.On("CreateToken", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("service unavailable"))
+
+	ui := cli.NewMockUi()
+	authenticator := &LoginAuthenticator{UI: ui, Client: mockClient, BrowserOpener: func(string) error { return nil }}
+
+	token, err := authenticator.Authenticate(context.Background(), appCfg)
+	assert.Nil(t, token)
+	assert.ErrorContains(t, err, "service unavailable")
+
+	// Failed logins are not cached
+	_, err = readCachedToken(appCfg)
+	assert.Error(t, err)
+}
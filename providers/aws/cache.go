@@ -0,0 +1,162 @@
+package aws
+
+import (
+	"crypto/sha1" //nolint:gosec // The AWS CLI names cache files after the SHA-1 of the session
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/go-homedir"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// SSOCacheEntry is a token cached in the AWS CLI SSO cache. The JSON schema
+// matches the AWS CLI so that both tools share one login.
+type SSOCacheEntry struct {
+	AccessToken           string     `json:"accessToken"`
+	ExpiresAt             time.Time  `json:"expiresAt"`
+	Region                string     `json:"region,omitempty"`
+	StartURL              string     `json:"startUrl,omitempty"`
+	ClientID              string     `json:"clientId,omitempty"`
+	ClientSecret          string     `json:"clientSecret,omitempty"`
+	RegistrationExpiresAt *time.Time `json:"registrationExpiresAt,omitempty"`
+	RefreshToken          string     `json:"refreshToken,omitempty"`
+}
+
+// validAt reports whether the entry holds an access token which is still valid
+// for at least skew after now
+func (e *SSOCacheEntry) validAt(now time.Time, skew time.Duration) bool {
+	// If it didn't fill in the fields, it is probably not a cache entry, some other random json
+	if e.AccessToken == "" || e.ExpiresAt.IsZero() {
+		return false
+	}
+	return now.Add(skew).Before(e.ExpiresAt)
+}
+
+// matches reports whether the entry belongs to the configured SSO portal. Entries
+// written without a region match any region.
+func (e *SSOCacheEntry) matches(appCfg *appconfig.Config) bool {
+	if normalizeStartURL(e.StartURL) != normalizeStartURL(appCfg.SSOStartURL()) {
+		return false
+	}
+	return e.Region == "" || e.Region == appCfg.SSORegion()
+}
+
+// normalizeStartURL removes the differences between equivalent start URLs
+func normalizeStartURL(startURL string) string {
+	return strings.TrimSuffix(strings.TrimSpace(startURL), "/")
+}
+
+// ssoCacheDir returns the SSO cache directory, which can be overridden with
+// AWS_SSO_CACHE_PATH
+func ssoCacheDir() (string, error) {
+	if dir := os.Getenv("AWS_SSO_CACHE_PATH"); dir != "" {
+		return dir, nil
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".aws", "sso", "cache"), nil
+}
+
+// cacheKey returns the value the AWS CLI hashes to name a token cache file: the
+// session name for sso-session profiles and the start URL for legacy profiles
+func cacheKey(appCfg *appconfig.Config) string {
+	if appCfg.UsesSSOSession() {
+		return appCfg.SSO.SessionName
+	}
+	return appCfg.SSOStartURL()
+}
+
+// cacheFilePath returns the path of the token cache file for the configuration
+func cacheFilePath(appCfg *appconfig.Config) (string, error) {
+	dir, err := ssoCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(cacheKey(appCfg))) //nolint:gosec // Not used for security
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// newCacheEntry returns the cache entry for a token created with the client registration
+func newCacheEntry(appCfg *appconfig.Config, register *ssooidc.RegisterClientOutput, token *ssooidc.CreateTokenOutput, now time.Time) SSOCacheEntry {
+	entry := SSOCacheEntry{
+		AccessToken:  aws.ToString(token.AccessToken),
+		ExpiresAt:    now.Add(time.Duration(token.ExpiresIn) * time.Second).UTC().Truncate(time.Second),
+		Region:       appCfg.SSORegion(),
+		StartURL:     appCfg.SSOStartURL(),
+		ClientID:     aws.ToString(register.ClientId),
+		ClientSecret: aws.ToString(register.ClientSecret),
+		RefreshToken: aws.ToString(token.RefreshToken),
+	}
+	if register.ClientSecretExpiresAt > 0 {
+		expiresAt := time.Unix(register.ClientSecretExpiresAt, 0).UTC()
+		entry.RegistrationExpiresAt = &expiresAt
+	}
+	return entry
+}
+
+// readCachedToken reads the token cache file for the configuration
+func readCachedToken(appCfg *appconfig.Config) (*SSOCacheEntry, error) {
+	path, err := cacheFilePath(appCfg)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry SSOCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &entry, nil
+}
+
+// writeCachedToken writes the token cache file for the configuration, replacing
+// any existing file atomically
+func writeCachedToken(appCfg *appconfig.Config, entry SSOCacheEntry) error {
+	path, err := cacheFilePath(appCfg)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode token cache: %w", err)
+	}
+	return writeCacheFile(path, data)
+}
+
+// writeCacheFile writes a file readable only by the user to the SSO cache,
+// creating the cache directory if needed and replacing any existing file atomically
+func writeCacheFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmpFile, err := os.CreateTemp(dir, ".cache-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package aws
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/go-homedir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// testCacheConfig returns an app config and points the SSO cache at a temporary directory
+func testCacheConfig(t *testing.T) (*appconfig.Config, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("AWS_SSO_CACHE_PATH", dir)
+
+	appCfg := appconfig.Default()
+	appCfg.SSO.StartURL = "https://test.awsapps.com/start"
+	appCfg.SSO.Region = "us-west-2"
+	return appCfg, dir
+}
+
+func TestSSOCacheDir(t *testing.T) {
+	t.Setenv("AWS_SSO_CACHE_PATH", "/custom/cache")
+	dir, err := ssoCacheDir()
+	require.NoError(t, err)
+	assert.Equal(t, "/custom/cache", dir)
+
+	// homedir caches the home directory, which would ignore HOME
+	homedir.DisableCache = true
+	t.Cleanup(func() { homedir.DisableCache = false })
+
+	home := t.TempDir()
+	t.Setenv("AWS_SSO_CACHE_PATH", "")
+	t.Setenv("HOME", home)
+	dir, err = ssoCacheDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".aws", "sso", "cache"), dir)
+}
+
+func TestCacheFilePath(t *testing.T) {
+	appCfg, dir := testCacheConfig(t)
+
+	// Legacy profiles are keyed by the start URL, like the AWS CLI
+	path, err := cacheFilePath(appCfg)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "bfe9e37c85cc299e34d8c03b631672483f78cd01.json"), path)
+
+	// sso-session profiles are keyed by the session name
+	appCfg.SSO.SessionName = "my-org"
+	appCfg.Generate.ProfileFormat = appconfig.ProfileFormatSSOSession
+	path, err = cacheFilePath(appCfg)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "063b06ca21ff862fb6d05839b8f962c8f1afdec6.json"), path)
+}
+
+func TestNewCacheEntry(t *testing.T) {
+	appCfg, _ := testCacheConfig(t)
+	now := time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)
+
+	entry := newCacheEntry(appCfg,
+		&ssooidc.RegisterClientOutput{
+			ClientId:              aws.String("client-id"),
+			ClientSecret:          aws.String("client-secret"),
+			ClientSecretExpiresAt: now.Add(90 * 24 * time.Hour).Unix(),
+		},
+		&ssooidc.CreateTokenOutput{
+			AccessToken:  aws.String("access-token"),
+			RefreshToken: aws.String("refresh-token"),
+			ExpiresIn:    3600,
+		},
+		now)
+
+	data, err := json.Marshal(entry)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"accessToken": "access-token",
+		"expiresAt": "2024-01-02T04:04:05Z",
+		"region": "us-west-2",
+		"startUrl": "https://test.awsapps.com/start",
+		"clientId": "client-id",
+		"clientSecret": "client-secret",
+		"registrationExpiresAt": "2024-04-01T03:04:05Z",
+		"refreshToken": "refresh-token"
+	}`, string(data))
+}
+
+func TestWriteAndReadCachedToken(t *testing.T) {
+	appCfg, dir := testCacheConfig(t)
+	// The cache directory is created when missing
+	dir = filepath.Join(dir, "nested")
+	t.Setenv("AWS_SSO_CACHE_PATH", dir)
+
+	_, err := readCachedToken(appCfg)
+	assert.True(t, os.IsNotExist(err))
+
+	entry := SSOCacheEntry{
+		AccessToken: "access-token",
+		ExpiresAt:   time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+		StartURL:    appCfg.SSOStartURL(),
+	}
+	require.NoError(t, writeCachedToken(appCfg, entry))
+
+	path, err := cacheFilePath(appCfg)
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	cached, err := readCachedToken(appCfg)
+	require.NoError(t, err)
+	assert.Equal(t, entry.AccessToken, cached.AccessToken)
+	assert.True(t, entry.ExpiresAt.Equal(cached.ExpiresAt))
+
+	// No temporary files are left behind
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestGenerateTokenWithConfigUsesCache(t *testing.T) {
+	appCfg, _ := testCacheConfig(t)
+	require.NoError(t, writeCachedToken(appCfg, SSOCacheEntry{
+		AccessToken: "cached-token",
+		ExpiresAt:   time.Now().Add(time.Hour),
+		StartURL:    appCfg.SSOStartURL(),
+		Region:      appCfg.SSORegion(),
+	}))
+
+	// A valid cached token is returned without logging in
+	token, err := GenerateTokenWithConfig(cli.NewMockUi(), aws.Config{}, appCfg)
+	require.NoError(t, err)
+	require.NotNil(t, token)
+	assert.Equal(t, "cached-token", *token)
+}
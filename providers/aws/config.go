@@ -4,27 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
-	"os/user"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
 	"github.com/mitchellh/go-homedir"
-	"github.com/pkg/browser"
 
 	appconfig "github.com/blairham/aws-sso-config/providers/config"
 )
 
-type SSOCacheEntry struct {
-	AccessToken string    `json:"accessToken"`
-	ExpiresAt   time.Time `json:"expiresAt"`
-}
-
 func ToString(p *string) string {
 	return aws.ToString(p)
 }
@@ -38,7 +30,8 @@ func ConfigFile() (string, error) {
 	return home + "/.aws/config", nil
 }
 
-func LoadDefaultConfig() aws.Config {
+// LoadDefaultConfig loads the shared AWS configuration used by the SSO clients
+func LoadDefaultConfig() (aws.Config, error) {
 	// load default aws config
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -47,63 +40,30 @@ func LoadDefaultConfig() aws.Config {
 		ctx,
 		config.WithRegion("us-east-1"))
 	if err != nil {
-		log.Fatal("error: ", err)
-	}
-
-	return cfg
-}
-
-func generateToken(cfg aws.Config) *string {
-	// create sso oidc client to trigger login flow
-	ssooidcClient := ssooidc.NewFromConfig(cfg)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
-
-	// register your client which is triggering the login flow
-	register, err := ssooidcClient.RegisterClient(ctx, &ssooidc.RegisterClientInput{
-		ClientName: aws.String("sample-client-name"),
-		ClientType: aws.String("public"),
-		Scopes:     []string{"sso-portal:*"},
-	})
-	if err != nil {
-		fmt.Printf("Failed to register client: %v\n", err)
-		return nil
-	}
-
-	// authorize your device using the client registration response
-	deviceAuth, err := ssooidcClient.StartDeviceAuthorization(ctx, &ssooidc.StartDeviceAuthorizationInput{
-		ClientId:     register.ClientId,
-		ClientSecret: register.ClientSecret,
-		StartUrl:     aws.String("https://your-sso-portal.awsapps.com/start"), // Replace with your SSO URL
-	})
-	if err != nil {
-		fmt.Printf("Failed to start device authorization: %v\n", err)
-		return nil
+		return aws.Config{}, fmt.Errorf("failed to load AWS configuration: %w", err)
 	}
 
-	// trigger OIDC login. open browser to login and wait for authorization
-	url := aws.ToString(deviceAuth.VerificationUriComplete)
-	fmt.Printf("Opening browser for AWS SSO login...\n%v\n", url)
-	err = browser.OpenURL(url)
-	if err != nil {
-		fmt.Printf("Failed to open browser automatically. Please manually open: %v\n", url)
-	}
-
-	fmt.Println("Waiting for authorization... (this may take a few moments)")
-
-	return pollForToken(ssooidcClient, register, deviceAuth)
+	return cfg, nil
 }
 
-func getCurrentToken() *string {
+// CachedToken returns the cached token for the configured SSO portal. The cache
+// file the AWS CLI would use for the configuration is checked first, then any
+// other entry for the same start URL and region. Tokens which expire within the
+// configured skew are ignored, and nil is returned when there is no valid token.
+func CachedToken(appCfg *appconfig.Config) *SSOCacheEntry {
 	// Best effort attempt to get token from sso cache.
 	// If you can't for whatever reason, return nil, and the code will walk the user through generating a token
+	now := time.Now()
+	skew := appCfg.SSO.ExpirySkew()
 
-	usr, err := user.Current()
+	if entry, err := readCachedToken(appCfg); err == nil && entry.matches(appCfg) && entry.validAt(now, skew) {
+		return entry
+	}
+
+	dir, err := ssoCacheDir()
 	if err != nil {
 		return nil
 	}
-	dir := filepath.Join(usr.HomeDir, ".aws/sso/cache")
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil
@@ -124,115 +84,37 @@ func getCurrentToken() *string {
 		if err != nil {
 			continue
 		}
-		// If it didn't fill in the fields, it is probably not a cache entry, some other random json
-		if cacheEntry.AccessToken == "" || cacheEntry.ExpiresAt.IsZero() {
-			continue
-		}
-		// Make sure it's not already expired
-		if time.Now().After(cacheEntry.ExpiresAt) {
+		// Tokens for other portals would fail with 401s
+		if !cacheEntry.matches(appCfg) || !cacheEntry.validAt(now, skew) {
 			continue
 		}
 
-		return &cacheEntry.AccessToken
+		return &cacheEntry
 	}
 
 	return nil
 }
 
-func GetToken(cfg aws.Config) *string {
-	token := getCurrentToken()
-	if token != nil {
-		return token
+// getCurrentToken returns the cached access token for the configured SSO portal
+func getCurrentToken(appCfg *appconfig.Config) *string {
+	if entry := CachedToken(appCfg); entry != nil {
+		return &entry.AccessToken
 	}
-
-	return generateToken(cfg)
+	return nil
 }
 
-func pollForToken(ssooidcClient *ssooidc.Client, register *ssooidc.RegisterClientOutput, deviceAuth *ssooidc.StartDeviceAuthorizationOutput) *string {
-	// Poll for token creation with exponential backoff
-	var token *ssooidc.CreateTokenOutput
-	var err error
-	maxAttempts := 30 // About 5 minutes total
-	interval := time.Second * 5
-
-	tokenCtx, tokenCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer tokenCancel()
-
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		token, err = ssooidcClient.CreateToken(tokenCtx, &ssooidc.CreateTokenInput{
-			ClientId:     register.ClientId,
-			ClientSecret: register.ClientSecret,
-			DeviceCode:   deviceAuth.DeviceCode,
-			GrantType:    aws.String("urn:ietf:params:oauth:grant-type:device_code"),
-		})
-
-		if err == nil {
-			fmt.Println("✓ Authorization successful!")
-			break
-		}
-
-		// Check if this is an authorization pending error (expected while waiting)
-		if strings.Contains(err.Error(), "authorization_pending") || strings.Contains(err.Error(), "slow_down") {
-			if attempt%6 == 0 { // Print status every 30 seconds
-				fmt.Printf("Still waiting for authorization... (attempt %d/%d)\n", attempt, maxAttempts)
-			}
-			time.Sleep(interval)
-			continue
-		}
-
-		// For other errors, break immediately
-		fmt.Printf("Authorization error: %v\n", err)
-		break
-	}
-
-	if err != nil {
-		if strings.Contains(err.Error(), "authorization_pending") {
-			fmt.Println("Authorization timeout. Please try again.")
-		}
-		return nil
-	}
-
-	return token.AccessToken
+// GetToken returns the access token for the configured SSO portal, using the
+// same cache lookup, refresh and login as GenerateTokenWithConfig
+func GetToken(ui cli.Ui, cfg aws.Config, appCfg *appconfig.Config) (*string, error) {
+	return GenerateTokenWithConfig(ui, cfg, appCfg)
 }
 
-func GenerateTokenWithConfig(cfg aws.Config, appCfg *appconfig.Config) *string {
-	// create sso oidc client to trigger login flow
-	ssooidcClient := ssooidc.NewFromConfig(cfg)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
-
-	// register your client which is triggering the login flow
-	register, err := ssooidcClient.RegisterClient(ctx, &ssooidc.RegisterClientInput{
-		ClientName: aws.String("aws-sso-config-cli"),
-		ClientType: aws.String("public"),
-		Scopes:     []string{"sso-portal:*"},
-	})
-	if err != nil {
-		fmt.Printf("Failed to register client: %v\n", err)
-		return nil
-	}
-
-	// authorize your device using the client registration response
-	deviceAuth, err := ssooidcClient.StartDeviceAuthorization(ctx, &ssooidc.StartDeviceAuthorizationInput{
-		ClientId:     register.ClientId,
-		ClientSecret: register.ClientSecret,
-		StartUrl:     aws.String(appCfg.SSOStartURL()),
-	})
-	if err != nil {
-		fmt.Printf("Failed to start device authorization: %v\n", err)
-		return nil
-	}
-
-	// trigger OIDC login. open browser to login and wait for authorization
-	url := aws.ToString(deviceAuth.VerificationUriComplete)
-	fmt.Printf("Opening browser for AWS SSO login...\n%v\n", url)
-	err = browser.OpenURL(url)
-	if err != nil {
-		fmt.Printf("Failed to open browser automatically. Please manually open: %v\n", url)
-	}
-
-	fmt.Println("Waiting for authorization... (this may take a few moments)")
-
-	return pollForToken(ssooidcClient, register, deviceAuth)
+// GenerateTokenWithConfig returns the cached access token for the configured SSO
+// portal. An expired token is refreshed with the cached refresh token when possible,
+// otherwise the user logs in with the configured login flow. New tokens are cached
+// in the AWS CLI SSO cache so that both this tool and the AWS CLI share the login.
+// Progress is shown on the UI. ErrLoginCanceled is returned when the user stops
+// the login.
+func GenerateTokenWithConfig(ui cli.Ui, cfg aws.Config, appCfg *appconfig.Config) (*string, error) {
+	return newAWSProvider(ui, cfg, appCfg).GenerateToken(appCfg)
 }
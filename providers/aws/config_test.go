@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/mitchellh/cli"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -17,7 +18,8 @@ import (
 // TestToString and TestConfigFile already exist in profiles_test.go
 
 func TestLoadDefaultConfig(t *testing.T) {
-	cfg := LoadDefaultConfig()
+	cfg, err := LoadDefaultConfig()
+	require.NoError(t, err)
 	// Should have a non-empty region
 	assert.NotEmpty(t, cfg.Region)
 }
@@ -70,10 +72,11 @@ default_region: "eu-west-1"
 
 	// We won't actually test the token generation which requires real AWS SSO
 	// Just ensure the function doesn't panic or crash
-	cfg := LoadDefaultConfig()
+	cfg, err := LoadDefaultConfig()
+	require.NoError(t, err)
 
-	// Either this should return nil (common) or potentially a token if SSO cache exists
-	token := GenerateTokenWithConfig(cfg, appCfg)
+	// Either this should return an error (common) or potentially a token if SSO cache exists
+	token, _ := GenerateTokenWithConfig(cli.NewMockUi(), cfg, appCfg)
 
 	// The real test is that we don't panic or crash
 	if token != nil {
@@ -84,117 +87,131 @@ default_region: "eu-west-1"
 
 // Additional test to ensure the getCurrentToken function handles missing cache directory correctly
 func TestGetCurrentTokenWithMissingDirectory(t *testing.T) {
-	// Back up original AWS_SSO_CACHE_PATH value
-	originalPath := os.Getenv("AWS_SSO_CACHE_PATH")
-	defer os.Setenv("AWS_SSO_CACHE_PATH", originalPath)
+	appCfg, dir := testCacheConfig(t)
 
 	// Set to a non-existent directory
-	nonExistentPath := filepath.Join(t.TempDir(), "does-not-exist")
-	os.Setenv("AWS_SSO_CACHE_PATH", nonExistentPath)
+	t.Setenv("AWS_SSO_CACHE_PATH", filepath.Join(dir, "does-not-exist"))
 
 	// Calling getCurrentToken should return nil without crashing
-	token := getCurrentToken()
+	token := getCurrentToken(appCfg)
 	assert.Nil(t, token)
 }
 
 // Test handling of invalid JSON in cache files
 func TestGetCurrentTokenWithInvalidCache(t *testing.T) {
-	// Create a temporary directory for the test
-	tempDir := t.TempDir()
-
-	// Back up original AWS_SSO_CACHE_PATH value
-	originalPath := os.Getenv("AWS_SSO_CACHE_PATH")
-	defer os.Setenv("AWS_SSO_CACHE_PATH", originalPath)
-
-	// Set to our temporary directory
-	os.Setenv("AWS_SSO_CACHE_PATH", tempDir)
+	appCfg, dir := testCacheConfig(t)
 
 	// Create invalid cache file
 	invalidJson := `{"accessToken": "test-token", "expiresAt": "not-a-valid-time"}`
-	cacheFilePath := filepath.Join(tempDir, "invalid-cache.json")
+	cacheFilePath := filepath.Join(dir, "invalid-cache.json")
 	err := os.WriteFile(cacheFilePath, []byte(invalidJson), 0600)
 	require.NoError(t, err)
 
 	// Calling getCurrentToken should return nil without crashing
-	token := getCurrentToken()
+	token := getCurrentToken(appCfg)
 	assert.Nil(t, token)
 }
 
 // TestGetCurrentToken tests the getCurrentToken function
 func TestGetCurrentToken(t *testing.T) {
-	// Create a temporary directory for testing
-	tmpDir := t.TempDir()
+	appCfg, cacheDir := testCacheConfig(t)
 
-	// Override the user's home directory for this test
-	originalHome := os.Getenv("HOME")
-	os.Setenv("HOME", tmpDir)
-	defer os.Setenv("HOME", originalHome)
-
-	// Create the .aws/sso/cache directory
-	cacheDir := filepath.Join(tmpDir, ".aws", "sso", "cache")
-	err := os.MkdirAll(cacheDir, 0750)
-	require.NoError(t, err)
+	writeCache := func(name string, content string) {
+		t.Helper()
+		err := os.WriteFile(filepath.Join(cacheDir, name), []byte(content), 0600)
+		require.NoError(t, err)
+	}
+	cacheJSON := func(token, startURL, region string, expiresIn time.Duration) string {
+		return `{
+		"accessToken": "` + token + `",
+		"startUrl": "` + startURL + `",
+		"region": "` + region + `",
+		"expiresAt": "` + time.Now().Add(expiresIn).UTC().Format(time.RFC3339) + `"
+	}`
+	}
 
 	// Test with no cache files
-	token := getCurrentToken()
+	token := getCurrentToken(appCfg)
 	assert.Nil(t, token, "Should return nil when no cache files exist")
 
+	// Tokens for another portal or region are never used
+	writeCache("other-portal.json", cacheJSON("other-portal-token", "https://other.awsapps.com/start", "us-west-2", time.Hour))
+	writeCache("other-region.json", cacheJSON("other-region-token", "https://test.awsapps.com/start", "eu-west-1", time.Hour))
+	token = getCurrentToken(appCfg)
+	assert.Nil(t, token, "Should return nil when no cache entry matches the portal")
+
 	// Create a valid cache file
-	validCache := `{
-		"accessToken": "valid-token-123",
-		"expiresAt": "` + time.Now().Add(1*time.Hour).Format(time.RFC3339) + `"
-	}`
-	cacheFile := filepath.Join(cacheDir, "valid-cache.json")
-	err = os.WriteFile(cacheFile, []byte(validCache), 0600)
-	require.NoError(t, err)
+	writeCache("valid-cache.json", cacheJSON("valid-token-123", "https://test.awsapps.com/start/", "us-west-2", time.Hour))
 
 	// Test with valid cache file
-	token = getCurrentToken()
-	if token == nil {
-		t.Skip("getCurrentToken returned nil - may be due to environment differences")
-	}
-	assert.NotNil(t, token, "Should return token when valid cache exists")
-	if token != nil {
-		assert.Equal(t, "valid-token-123", *token, "Should return correct token")
-	}
-
-	// Create an expired cache file
-	expiredCache := `{
-		"accessToken": "expired-token-456",
-		"expiresAt": "` + time.Now().Add(-1*time.Hour).Format(time.RFC3339) + `"
-	}`
-	expiredCacheFile := filepath.Join(cacheDir, "expired-cache.json")
-	err = os.WriteFile(expiredCacheFile, []byte(expiredCache), 0600)
-	require.NoError(t, err)
+	token = getCurrentToken(appCfg)
+	require.NotNil(t, token, "Should return token when valid cache exists")
+	assert.Equal(t, "valid-token-123", *token, "Should return correct token")
 
 	// Remove the valid cache file
-	os.Remove(cacheFile)
+	os.Remove(filepath.Join(cacheDir, "valid-cache.json"))
+
+	// Create an expired cache file
+	writeCache("expired-cache.json", cacheJSON("expired-token-456", "https://test.awsapps.com/start", "us-west-2", -time.Hour))
 
 	// Test with only expired cache file
-	token = getCurrentToken()
+	token = getCurrentToken(appCfg)
 	assert.Nil(t, token, "Should return nil when only expired cache exists")
 
+	// Tokens expiring within the skew are treated as expired
+	writeCache("expiring-cache.json", cacheJSON("expiring-token", "https://test.awsapps.com/start", "us-west-2", 2*time.Minute))
+	token = getCurrentToken(appCfg)
+	assert.Nil(t, token, "Should return nil when the token expires within the skew")
+
+	appCfg.SSO.TokenExpirySkew = "1m"
+	token = getCurrentToken(appCfg)
+	require.NotNil(t, token, "Should return token which outlives a shorter skew")
+	assert.Equal(t, "expiring-token", *token)
+	appCfg.SSO.TokenExpirySkew = "5m"
+
 	// Create an invalid JSON cache file
-	invalidCache := `{invalid json}`
-	invalidCacheFile := filepath.Join(cacheDir, "invalid-cache.json")
-	err = os.WriteFile(invalidCacheFile, []byte(invalidCache), 0600)
-	require.NoError(t, err)
+	writeCache("invalid-cache.json", `{invalid json}`)
 
 	// Test with invalid JSON cache file
-	token = getCurrentToken()
+	token = getCurrentToken(appCfg)
 	assert.Nil(t, token, "Should return nil when cache contains invalid JSON")
 
 	// Create a cache file with missing fields
-	incompleteCache := `{"someField": "value"}`
-	incompleteCacheFile := filepath.Join(cacheDir, "incomplete-cache.json")
-	err = os.WriteFile(incompleteCacheFile, []byte(incompleteCache), 0600)
-	require.NoError(t, err)
+	writeCache("incomplete-cache.json", `{"startUrl": "https://test.awsapps.com/start"}`)
 
 	// Test with incomplete cache file
-	token = getCurrentToken()
+	token = getCurrentToken(appCfg)
 	assert.Nil(t, token, "Should return nil when cache is missing required fields")
 }
 
+// TestGetCurrentTokenPrefersSessionCache verifies the cache file of the sso-session is used first
+func TestGetCurrentTokenPrefersSessionCache(t *testing.T) {
+	appCfg, cacheDir := testCacheConfig(t)
+	appCfg.SSO.SessionName = "my-org"
+	appCfg.Generate.ProfileFormat = appconfig.ProfileFormatSSOSession
+
+	// An older login for the same portal sorts first in the directory
+	older := SSOCacheEntry{
+		AccessToken: "older-token",
+		ExpiresAt:   time.Now().Add(time.Hour),
+		StartURL:    appCfg.SSOStartURL(),
+	}
+	data, err := json.Marshal(older)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "0000.json"), data, 0600))
+
+	require.NoError(t, writeCachedToken(appCfg, SSOCacheEntry{
+		AccessToken: "session-token",
+		ExpiresAt:   time.Now().Add(time.Hour),
+		StartURL:    appCfg.SSOStartURL(),
+		Region:      appCfg.SSORegion(),
+	}))
+
+	token := getCurrentToken(appCfg)
+	require.NotNil(t, token)
+	assert.Equal(t, "session-token", *token)
+}
+
 // TestGetTokenWithCache tests GetToken when cache exists
 func TestGetTokenWithCache(t *testing.T) {
 	// Skip this test to avoid opening browser windows during testing
@@ -230,10 +247,11 @@ func TestGetTokenWithCache(t *testing.T) {
 	require.NoError(t, err)
 
 	// Load default config
-	cfg := LoadDefaultConfig()
+	cfg, err := LoadDefaultConfig()
+	require.NoError(t, err)
 
 	// Test GetToken - should return cached token and not open browser
-	token := GetToken(cfg)
+	token, _ := GetToken(cli.NewMockUi(), cfg, appconfig.Default())
 	if token == nil {
 		t.Skip("GetToken returned nil - may be due to environment differences or AWS SSO not being available")
 	}
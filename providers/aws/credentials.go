@@ -0,0 +1,187 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/bigkevmcd/go-configparser"
+	"github.com/mitchellh/cli"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// SSOProfile is a profile of the AWS config file which signs in with AWS SSO
+type SSOProfile struct {
+	Name      string
+	AccountID string
+	RoleName  string
+	// Region is the region of the profile, which may differ from the SSO region
+	Region string
+
+	StartURL  string
+	SSORegion string
+	// SessionName is the [sso-session] section the profile references, if any
+	SessionName        string
+	RegistrationScopes string
+}
+
+// LoadSSOProfile reads the named profile from the AWS config file. Profiles may
+// reference an [sso-session] section or hold the start URL and SSO region
+// themselves, as generated in the legacy format.
+func LoadSSOProfile(configFile, name string) (*SSOProfile, error) {
+	awsConfig, err := configparser.NewConfigParserFromFile(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", configFile, err)
+	}
+
+	section := "profile " + name
+	if name == "default" && !awsConfig.HasSection(section) {
+		section = "default"
+	}
+	if !awsConfig.HasSection(section) {
+		return nil, fmt.Errorf("profile %q not found in %s", name, configFile)
+	}
+
+	// Missing keys are reported below
+	get := func(key string) string {
+		value, _ := awsConfig.Get(section, key)
+		return value
+	}
+	profile := &SSOProfile{
+		Name:      name,
+		AccountID: get("sso_account_id"),
+		RoleName:  get("sso_role_name"),
+		Region:    get("region"),
+	}
+	if profile.AccountID == "" || profile.RoleName == "" {
+		return nil, fmt.Errorf("profile %q is not an SSO profile: sso_account_id and sso_role_name are required", name)
+	}
+
+	if sessionName := get("sso_session"); sessionName != "" {
+		session, err := readSSOSession(awsConfig, configFile, sessionName)
+		if err != nil {
+			return nil, err
+		}
+		profile.SessionName = sessionName
+		profile.StartURL = session.StartURL
+		profile.SSORegion = session.Region
+		profile.RegistrationScopes = session.RegistrationScopes
+	} else {
+		profile.StartURL = get("sso_start_url")
+		profile.SSORegion = get("sso_region")
+		if profile.StartURL == "" || profile.SSORegion == "" {
+			return nil, fmt.Errorf("profile %q has no sso_session or sso_start_url and sso_region", name)
+		}
+	}
+	if profile.Region == "" {
+		profile.Region = profile.SSORegion
+	}
+
+	return profile, nil
+}
+
+// Configure returns a copy of the configuration pointing at the profile's SSO
+// portal, so that its token is cached where the AWS CLI caches it
+func (p *SSOProfile) Configure(appCfg *appconfig.Config) *appconfig.Config {
+	profileCfg := *appCfg
+	profileCfg.SSO.StartURL = p.StartURL
+	profileCfg.SSO.Region = p.SSORegion
+	if p.SessionName != "" {
+		profileCfg.SSO.SessionName = p.SessionName
+		profileCfg.Generate.ProfileFormat = appconfig.ProfileFormatSSOSession
+	} else {
+		profileCfg.Generate.ProfileFormat = appconfig.ProfileFormatLegacy
+	}
+	if p.RegistrationScopes != "" {
+		profileCfg.SSO.RegistrationScopes = p.RegistrationScopes
+	}
+	return &profileCfg
+}
+
+// RoleCredentialsClient is the SSO operation used to get role credentials,
+// allowing mocking in tests
+type RoleCredentialsClient interface {
+	GetRoleCredentials(ctx context.Context, params *sso.GetRoleCredentialsInput, optFns ...func(*sso.Options)) (*sso.GetRoleCredentialsOutput, error)
+}
+
+// RoleCredentials are temporary credentials for an SSO role
+type RoleCredentials struct {
+	AccessKeyID     string    `json:"accessKeyId"`
+	SecretAccessKey string    `json:"secretAccessKey"`
+	SessionToken    string    `json:"sessionToken"`
+	Expiration      time.Time `json:"expiration"`
+}
+
+// GetRoleCredentials returns temporary credentials for the profile's account and role
+func GetRoleCredentials(ctx context.Context, client RoleCredentialsClient, token string, profile *SSOProfile) (*RoleCredentials, error) {
+	output, err := client.GetRoleCredentials(ctx, &sso.GetRoleCredentialsInput{
+		AccessToken: aws.String(token),
+		AccountId:   aws.String(profile.AccountID),
+		RoleName:    aws.String(profile.RoleName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials for role %s in account %s: %w", profile.RoleName, profile.AccountID, err)
+	}
+	if output.RoleCredentials == nil || aws.ToString(output.RoleCredentials.AccessKeyId) == "" {
+		return nil, fmt.Errorf("no credentials returned for role %s in account %s", profile.RoleName, profile.AccountID)
+	}
+
+	return &RoleCredentials{
+		AccessKeyID:     aws.ToString(output.RoleCredentials.AccessKeyId),
+		SecretAccessKey: aws.ToString(output.RoleCredentials.SecretAccessKey),
+		SessionToken:    aws.ToString(output.RoleCredentials.SessionToken),
+		// The expiration is in milliseconds since the epoch
+		Expiration: time.UnixMilli(output.RoleCredentials.Expiration).UTC(),
+	}, nil
+}
+
+// ProfileCredentials returns credentials for the profile's role from the role
+// credential cache, or authenticates to the profile's SSO portal and gets new
+// credentials, which are cached. The SSO client must be for the SSO region.
+func ProfileCredentials(
+	ctx context.Context,
+	ui cli.Ui,
+	authenticator Authenticator,
+	client RoleCredentialsClient,
+	appCfg *appconfig.Config,
+	profile *SSOProfile,
+) (*RoleCredentials, error) {
+	if creds := lookupRoleCredentials(profile); creds != nil {
+		return creds, nil
+	}
+	// The login can wait on the user, so it happens before the cache is locked
+	token, err := authenticator.Authenticate(ctx, profile.Configure(appCfg))
+	if err != nil {
+		return nil, err
+	}
+	return cachedRoleCredentials(ui, profile, func() (*RoleCredentials, error) {
+		return GetRoleCredentials(ctx, client, aws.ToString(token), profile)
+	})
+}
+
+// EnvVar is an environment variable
+type EnvVar struct {
+	Name  string
+	Value string
+}
+
+// Environment returns the environment variables passing the credentials and
+// region to the AWS CLI and SDKs. AWS_CREDENTIAL_EXPIRATION tells wrappers when
+// the credentials have to be replaced.
+func (c *RoleCredentials) Environment(region string) []EnvVar {
+	env := []EnvVar{
+		{"AWS_ACCESS_KEY_ID", c.AccessKeyID},
+		{"AWS_SECRET_ACCESS_KEY", c.SecretAccessKey},
+		{"AWS_SESSION_TOKEN", c.SessionToken},
+	}
+	if !c.Expiration.IsZero() {
+		env = append(env, EnvVar{"AWS_CREDENTIAL_EXPIRATION", c.Expiration.UTC().Format(time.RFC3339)})
+	}
+	if region != "" {
+		env = append(env, EnvVar{"AWS_REGION", region}, EnvVar{"AWS_DEFAULT_REGION", region})
+	}
+	return env
+}
@@ -0,0 +1,264 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// Mock implementation of RoleCredentialsClient interface for testing
+type MockRoleCredentialsClient struct {
+	mock.Mock
+}
+
+func (m *MockRoleCredentialsClient) GetRoleCredentials(
+	ctx context.Context,
+	params *sso.GetRoleCredentialsInput,
+	optFns ...func(*sso.Options),
+) (*sso.GetRoleCredentialsOutput, error) {
+	args := m.Called(ctx, params, optFns)
+	result := args.Get(0)
+	if result == nil {
+		return nil, args.Error(1)
+	}
+	return result.(*sso.GetRoleCredentialsOutput), args.Error(1)
+}
+
+const testProfilesConfig = `[default]
+sso_account_id = 111111111111
+sso_role_name = ReadOnly
+sso_start_url = https://legacy.awsapps.com/start
+sso_region = us-west-2
+
+[profile prod]
+sso_session = my-sso
+sso_account_id = 222222222222
+sso_role_name = Admin
+region = eu-central-1
+
+[sso-session my-sso]
+sso_start_url = https://session.awsapps.com/start
+sso_region = eu-west-1
+sso_registration_scopes = sso:account:access
+
+[profile static]
+aws_access_key_id = AKIA
+
+[profile no-portal]
+sso_account_id = 333333333333
+sso_role_name = Admin
+`
+
+func testProfilesFile(t *testing.T) string {
+	t.Helper()
+	configFile := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(configFile, []byte(testProfilesConfig), 0600))
+	return configFile
+}
+
+func TestLoadSSOProfile(t *testing.T) {
+	configFile := testProfilesFile(t)
+
+	profile, err := LoadSSOProfile(configFile, "default")
+	require.NoError(t, err)
+	assert.Equal(t, &SSOProfile{
+		Name:      "default",
+		AccountID: "111111111111",
+		RoleName:  "ReadOnly",
+		Region:    "us-west-2",
+		StartURL:  "https://legacy.awsapps.com/start",
+		SSORegion: "us-west-2",
+	}, profile)
+
+	profile, err = LoadSSOProfile(configFile, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, &SSOProfile{
+		Name:               "prod",
+		AccountID:          "222222222222",
+		RoleName:           "Admin",
+		Region:             "eu-central-1",
+		StartURL:           "https://session.awsapps.com/start",
+		SSORegion:          "eu-west-1",
+		SessionName:        "my-sso",
+		RegistrationScopes: "sso:account:access",
+	}, profile)
+}
+
+func TestLoadSSOProfileErrors(t *testing.T) {
+	configFile := testProfilesFile(t)
+
+	tests := []struct {
+		name     string
+		profile  string
+		expected string
+	}{
+		{"missing profile", "missing", `profile "missing" not found`},
+		{"not an SSO profile", "static", "is not an SSO profile"},
+		{"no portal", "no-portal", "has no sso_session or sso_start_url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadSSOProfile(configFile, tt.profile)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+
+	_, err := LoadSSOProfile(filepath.Join(t.TempDir(), "missing"), "default")
+	assert.ErrorContains(t, err, "error reading")
+}
+
+func TestSSOProfileConfigure(t *testing.T) {
+	appCfg := appconfig.Default()
+
+	legacy := &SSOProfile{StartURL: "https://legacy.awsapps.com/start", SSORegion: "us-west-2"}
+	legacyCfg := legacy.Configure(appCfg)
+	assert.Equal(t, "https://legacy.awsapps.com/start", legacyCfg.SSOStartURL())
+	assert.Equal(t, "us-west-2", legacyCfg.SSORegion())
+	assert.Equal(t, "https://legacy.awsapps.com/start", cacheKey(legacyCfg))
+
+	session := &SSOProfile{StartURL: "https://session.awsapps.com/start", SSORegion: "eu-west-1", SessionName: "my-sso"}
+	sessionCfg := session.Configure(appCfg)
+	assert.Equal(t, "my-sso", cacheKey(sessionCfg))
+
+	// The configuration itself is not changed
+	assert.Equal(t, appconfig.Default().SSO, appCfg.SSO)
+}
+
+func TestGetRoleCredentials(t *testing.T) {
+	profile := &SSOProfile{AccountID: "222222222222", RoleName: "Admin"}
+	expiration := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+
+	mockClient := new(MockRoleCredentialsClient)
+	mockClient.On("GetRoleCredentials", mock.Anything, &sso.GetRoleCredentialsInput{
+		AccessToken: aws.String("access-token"),
+		AccountId:   aws.String("222222222222"),
+		RoleName:    aws.String("Admin"),
+	}, mock.Anything).Return(&sso.GetRoleCredentialsOutput{
+		RoleCredentials: &types.RoleCredentials{
+			AccessKeyId:     aws.String("ASIA"),
+			SecretAccessKey: aws.String("secret"),
+			SessionToken:    aws.String("session"),
+			Expiration:      expiration.UnixMilli(),
+		},
+	}, nil).Once()
+
+	creds, err := GetRoleCredentials(context.Background(), mockClient, "access-token", profile)
+	require.NoError(t, err)
+	assert.Equal(t, "ASIA", creds.AccessKeyID)
+	assert.Equal(t, "secret", creds.SecretAccessKey)
+	assert.Equal(t, "session", creds.SessionToken)
+	assert.True(t, expiration.Equal(creds.Expiration))
+	mockClient.AssertExpectations(t)
+
+	mockClient = new(MockRoleCredentialsClient)
+	mockClient.On("GetRoleCredentials", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("ForbiddenException"))
+	_, err = GetRoleCredentials(context.Background(), mockClient, "access-token", profile)
+	assert.ErrorContains(t, err, "failed to get credentials for role Admin in account 222222222222: ForbiddenException")
+
+	mockClient = new(MockRoleCredentialsClient)
+	mockClient.On("GetRoleCredentials", mock.Anything, mock.Anything, mock.Anything).Return(&sso.GetRoleCredentialsOutput{}, nil)
+	_, err = GetRoleCredentials(context.Background(), mockClient, "access-token", profile)
+	assert.ErrorContains(t, err, "no credentials returned")
+}
+
+func TestProfileCredentials(t *testing.T) {
+	t.Setenv("AWS_SSO_CACHE_PATH", t.TempDir())
+	profile := &SSOProfile{AccountID: "222222222222", RoleName: "Admin", StartURL: "https://session.awsapps.com/start", SSORegion: "eu-west-1"}
+
+	var authenticated *appconfig.Config
+	authenticator := authenticatorFunc(func(_ context.Context, appCfg *appconfig.Config) (*string, error) {
+		authenticated = appCfg
+		return aws.String("access-token"), nil
+	})
+
+	mockClient := new(MockRoleCredentialsClient)
+	mockClient.On("GetRoleCredentials", mock.Anything, mock.MatchedBy(func(input *sso.GetRoleCredentialsInput) bool {
+		return aws.ToString(input.AccessToken) == "access-token"
+	}), mock.Anything).Return(&sso.GetRoleCredentialsOutput{
+		RoleCredentials: &types.RoleCredentials{AccessKeyId: aws.String("ASIA")},
+	}, nil)
+
+	creds, err := ProfileCredentials(context.Background(), cli.NewMockUi(), authenticator, mockClient, appconfig.Default(), profile)
+	require.NoError(t, err)
+	assert.Equal(t, "ASIA", creds.AccessKeyID)
+	// The token is for the profile's portal
+	assert.Equal(t, "https://session.awsapps.com/start", authenticated.SSOStartURL())
+
+	// Failed logins are returned as they are
+	failing := authenticatorFunc(func(context.Context, *appconfig.Config) (*string, error) {
+		return nil, ErrLoginCanceled
+	})
+	_, err = ProfileCredentials(context.Background(), cli.NewMockUi(), failing, mockClient, appconfig.Default(), profile)
+	assert.ErrorIs(t, err, ErrLoginCanceled)
+}
+
+func TestProfileCredentialsLoginUnlocked(t *testing.T) {
+	t.Setenv("AWS_SSO_CACHE_PATH", t.TempDir())
+	profile := &SSOProfile{AccountID: "222222222222", RoleName: "Admin", StartURL: "https://session.awsapps.com/start", SSORegion: "eu-west-1"}
+
+	// Other processes can use the cache while the login waits on the user
+	authenticator := authenticatorFunc(func(context.Context, *appconfig.Config) (*string, error) {
+		opened := make(chan struct{})
+		go func() {
+			if cache, err := openRoleCredentialsCache(profile); err == nil {
+				cache.unlock()
+			}
+			close(opened)
+		}()
+		select {
+		case <-opened:
+		case <-time.After(5 * time.Second):
+			t.Error("the role credential cache is locked during the login")
+		}
+		return aws.String("access-token"), nil
+	})
+
+	mockClient := new(MockRoleCredentialsClient)
+	mockClient.On("GetRoleCredentials", mock.Anything, mock.Anything, mock.Anything).Return(&sso.GetRoleCredentialsOutput{
+		RoleCredentials: &types.RoleCredentials{AccessKeyId: aws.String("ASIA"), Expiration: time.Now().Add(time.Hour).UnixMilli()},
+	}, nil).Once()
+
+	creds, err := ProfileCredentials(context.Background(), cli.NewMockUi(), authenticator, mockClient, appconfig.Default(), profile)
+	require.NoError(t, err)
+	assert.Equal(t, "ASIA", creds.AccessKeyID)
+
+	// Cached credentials are returned without logging in
+	failing := authenticatorFunc(func(context.Context, *appconfig.Config) (*string, error) {
+		return nil, ErrLoginCanceled
+	})
+	cached, err := ProfileCredentials(context.Background(), cli.NewMockUi(), failing, mockClient, appconfig.Default(), profile)
+	require.NoError(t, err)
+	assert.Equal(t, creds, cached)
+	mockClient.AssertExpectations(t)
+}
+
+func TestRoleCredentialsEnvironment(t *testing.T) {
+	creds := &RoleCredentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "session"}
+
+	assert.Equal(t, []EnvVar{
+		{"AWS_ACCESS_KEY_ID", "ASIA"},
+		{"AWS_SECRET_ACCESS_KEY", "secret"},
+		{"AWS_SESSION_TOKEN", "session"},
+		{"AWS_REGION", "eu-central-1"},
+		{"AWS_DEFAULT_REGION", "eu-central-1"},
+	}, creds.Environment("eu-central-1"))
+
+	assert.Len(t, creds.Environment(""), 3)
+
+	creds.Expiration = time.Date(2030, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	assert.Equal(t, EnvVar{"AWS_CREDENTIAL_EXPIRATION", "2030-01-02T02:04:05Z"}, creds.Environment("")[3])
+}
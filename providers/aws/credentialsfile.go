@@ -0,0 +1,322 @@
+package aws
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bigkevmcd/go-configparser"
+	"github.com/mitchellh/go-homedir"
+)
+
+// Every section aws-sso-config writes, to the AWS config file or the shared
+// credentials file, is marked with this key so that it can be told apart from
+// hand-written sections. The AWS CLI and SDKs ignore unknown keys.
+const (
+	GeneratedByKey   = "generated_by"
+	GeneratedByValue = "aws-sso-config"
+)
+
+// Sections written to the shared credentials file also hold when their
+// credentials expire. They are written again from their values every time the
+// file is saved, with a comment above them saying when they expire.
+const (
+	credentialExpiryKey = "expiration"
+	expiryCommentPrefix = "# Written by aws-sso-config, expires at "
+)
+
+// credentialKeys are the keys of the sections aws-sso-config writes, in the order
+// they are written
+var credentialKeys = []string{
+	"aws_access_key_id",
+	"aws_secret_access_key",
+	"aws_session_token",
+	credentialExpiryKey,
+	GeneratedByKey,
+}
+
+// SharedCredentialsFile returns the path of the shared credentials file, which can
+// be overridden with AWS_SHARED_CREDENTIALS_FILE
+func SharedCredentialsFile() (string, error) {
+	if file := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); file != "" {
+		return file, nil
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".aws", "credentials"), nil
+}
+
+// GeneratedProfiles returns the names of the profiles generate wrote to the AWS
+// config file which match any of the patterns, in the syntax of path.Match
+func GeneratedProfiles(configFile string, patterns []string) ([]string, error) {
+	awsConfig, err := configparser.NewConfigParserFromFile(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", configFile, err)
+	}
+
+	var profiles []string
+	for _, section := range awsConfig.Sections() {
+		name, ok := strings.CutPrefix(section, "profile ")
+		if !ok || !IsGenerated(awsConfig, section) {
+			continue
+		}
+		for _, pattern := range patterns {
+			matched, err := path.Match(pattern, name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid profile pattern %q: %w", pattern, err)
+			}
+			if matched {
+				profiles = append(profiles, name)
+				break
+			}
+		}
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// IsGenerated reports whether the section was written by aws-sso-config
+func IsGenerated(parser *configparser.ConfigParser, section string) bool {
+	value, err := parser.Get(section, GeneratedByKey)
+	return err == nil && value == GeneratedByValue
+}
+
+// CredentialsFile is a shared credentials file holding static credentials. The
+// lines of the file as it was read are kept, so that saving it only changes the
+// sections aws-sso-config wrote.
+type CredentialsFile struct {
+	path   string
+	parser *configparser.ConfigParser
+	lines  []string
+	// generated holds the sections aws-sso-config had written when the file was read
+	generated map[string]bool
+}
+
+// LoadCredentialsFile reads the shared credentials file. A missing file is empty.
+func LoadCredentialsFile(path string) (*CredentialsFile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		data, err = nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	parser, err := configparser.ParseReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	file := &CredentialsFile{path: path, parser: parser, generated: make(map[string]bool)}
+	if len(data) > 0 {
+		file.lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+	for _, section := range parser.Sections() {
+		if IsGenerated(parser, section) {
+			file.generated[section] = true
+		}
+	}
+	return file, nil
+}
+
+// LoadSharedCredentialsFile reads the shared credentials file of the user
+func LoadSharedCredentialsFile() (*CredentialsFile, error) {
+	path, err := SharedCredentialsFile()
+	if err != nil {
+		return nil, err
+	}
+	return LoadCredentialsFile(path)
+}
+
+// Path returns the path of the file
+func (f *CredentialsFile) Path() string {
+	return f.path
+}
+
+// SetCredentials writes the credentials into the profile's section. Sections
+// which aws-sso-config did not write are not replaced.
+func (f *CredentialsFile) SetCredentials(profile string, creds *RoleCredentials) error {
+	if f.parser.HasSection(profile) {
+		if !IsGenerated(f.parser, profile) {
+			return fmt.Errorf("profile %s in %s was not written by aws-sso-config", profile, f.path)
+		}
+	} else if err := f.parser.AddSection(profile); err != nil {
+		return fmt.Errorf("failed to add profile %s: %w", profile, err)
+	}
+
+	values := map[string]string{
+		"aws_access_key_id":     creds.AccessKeyID,
+		"aws_secret_access_key": creds.SecretAccessKey,
+		"aws_session_token":     creds.SessionToken,
+		credentialExpiryKey:     creds.Expiration.UTC().Format(time.RFC3339),
+		GeneratedByKey:          GeneratedByValue,
+	}
+	for _, key := range credentialKeys {
+		if err := f.parser.Set(profile, key, values[key]); err != nil {
+			return fmt.Errorf("failed to set %s in profile %s: %w", key, profile, err)
+		}
+	}
+	return nil
+}
+
+// expiration returns when the credentials of a section written by aws-sso-config
+// expire. Sections without a valid expiry are treated as expired.
+func (f *CredentialsFile) expiration(section string) time.Time {
+	value, err := f.parser.Get(section, credentialExpiryKey)
+	if err != nil {
+		return time.Time{}
+	}
+	expiration, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return expiration
+}
+
+// RemoveExpired removes the sections aws-sso-config wrote whose credentials have
+// expired at now, returning their names. Other sections are never removed.
+func (f *CredentialsFile) RemoveExpired(now time.Time) []string {
+	var removed []string
+	for _, section := range f.parser.Sections() {
+		if IsGenerated(f.parser, section) && !now.Before(f.expiration(section)) {
+			_ = f.parser.RemoveSection(section)
+			removed = append(removed, section)
+		}
+	}
+	return removed
+}
+
+// Save writes the file readable only by the user, replacing it atomically. Only
+// the sections aws-sso-config wrote are changed: they are written again with a
+// comment saying when they expire, or dropped when removed, and new sections are
+// added at the end. Every other line, including comments, is kept as it was read.
+func (f *CredentialsFile) Save() error {
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	tmpFile, err := os.CreateTemp(dir, ".credentials-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	// The temporary file is readable only by the user, and keeps its mode when renamed
+	_, err = tmpFile.Write(f.render())
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	if err := os.Rename(tmpFile.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// render returns the content of the file: the lines it was read with, with the
+// sections aws-sso-config wrote replaced by their current values
+func (f *CredentialsFile) render() []byte {
+	var lines []string
+	// Comments and blank lines in sections aws-sso-config wrote, which are kept
+	// when they come before the next section
+	var pending []string
+	written := make(map[string]bool)
+	// Whether the lines belong to a section aws-sso-config wrote
+	generated := false
+	for _, line := range f.lines {
+		// Expiry comments are written again above the sections they belong to
+		if strings.HasPrefix(line, expiryCommentPrefix) {
+			continue
+		}
+		section, header := sectionHeader(line)
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case header:
+			lines = appendPending(lines, pending)
+			pending = nil
+			generated = f.generated[section]
+			if !generated {
+				lines = append(lines, line)
+			} else if IsGenerated(f.parser, section) && !written[section] {
+				lines = append(lines, f.renderSection(section)...)
+				written[section] = true
+			}
+		case !generated:
+			lines = append(lines, line)
+		case trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";"):
+			pending = append(pending, line)
+		default:
+			// The values were written again from the parser
+			pending = nil
+		}
+	}
+	lines = appendPending(lines, pending)
+
+	for _, section := range f.parser.Sections() {
+		if IsGenerated(f.parser, section) && !written[section] {
+			if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+				lines = append(lines, "")
+			}
+			lines = append(lines, f.renderSection(section)...)
+		}
+	}
+
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// appendPending appends the pending lines, without repeating blank lines left
+// where a section was removed
+func appendPending(lines, pending []string) []string {
+	for _, line := range pending {
+		if strings.TrimSpace(line) == "" && (len(lines) == 0 || strings.TrimSpace(lines[len(lines)-1]) == "") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// renderSection returns the lines of a section aws-sso-config wrote, preceded by
+// its expiry comment
+func (f *CredentialsFile) renderSection(section string) []string {
+	lines := []string{
+		expiryCommentPrefix + f.expiration(section).Format(time.RFC3339),
+		"[" + section + "]",
+	}
+	items, _ := f.parser.Items(section)
+	options, _ := f.parser.Options(section)
+	// The credential keys come first, in the order they are written
+	keys := slices.DeleteFunc(options, func(key string) bool { return slices.Contains(credentialKeys, key) })
+	keys = append(slices.Clone(credentialKeys), keys...)
+	for _, key := range keys {
+		if value, ok := items[key]; ok {
+			lines = append(lines, fmt.Sprintf("%s = %s", key, value))
+		}
+	}
+	return lines
+}
+
+// sectionHeader returns the name of the section a line starts
+func sectionHeader(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return "", false
+	}
+	return strings.TrimSpace(line[1 : len(line)-1]), true
+}
@@ -0,0 +1,222 @@
+package aws
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedCredentialsFile(t *testing.T) {
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/custom/credentials")
+	path, err := SharedCredentialsFile()
+	require.NoError(t, err)
+	assert.Equal(t, "/custom/credentials", path)
+
+	homedir.DisableCache = true
+	t.Cleanup(func() { homedir.DisableCache = false })
+
+	home := t.TempDir()
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "")
+	t.Setenv("HOME", home)
+	path, err = SharedCredentialsFile()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".aws", "credentials"), path)
+}
+
+func TestGeneratedProfiles(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(configFile, []byte(`[default]
+region = us-east-1
+
+[profile prod-app]
+sso_account_id = 111111111111
+generated_by = aws-sso-config
+
+[profile prod-data]
+sso_account_id = 222222222222
+generated_by = aws-sso-config
+
+[profile staging]
+sso_account_id = 333333333333
+generated_by = aws-sso-config
+
+[profile prod-manual]
+sso_account_id = 444444444444
+
+[sso-session my-sso]
+generated_by = aws-sso-config
+`), 0600))
+
+	tests := []struct {
+		name     string
+		patterns []string
+		expected []string
+	}{
+		{"all", []string{"*"}, []string{"prod-app", "prod-data", "staging"}},
+		{"pattern", []string{"prod-*"}, []string{"prod-app", "prod-data"}},
+		{"names", []string{"staging", "prod-app"}, []string{"prod-app", "staging"}},
+		{"not generated", []string{"prod-manual", "my-sso"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profiles, err := GeneratedProfiles(configFile, tt.patterns)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, profiles)
+		})
+	}
+
+	_, err := GeneratedProfiles(configFile, []string{"[prod"})
+	assert.ErrorContains(t, err, `invalid profile pattern "[prod"`)
+
+	_, err = GeneratedProfiles(filepath.Join(t.TempDir(), "missing"), []string{"*"})
+	assert.Error(t, err)
+}
+
+func TestCredentialsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".aws", "credentials")
+
+	// A missing file is empty
+	file, err := LoadCredentialsFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, file.Path())
+
+	expiration := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, file.SetCredentials("prod", &RoleCredentials{
+		AccessKeyID:     "ASIAEXAMPLE",
+		SecretAccessKey: "secret",
+		SessionToken:    "session",
+		Expiration:      expiration,
+	}))
+	require.NoError(t, file.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Written by aws-sso-config, expires at 2030-01-02T03:04:05Z\n[prod]\n")
+	assert.Contains(t, string(data), "aws_access_key_id = ASIAEXAMPLE\n")
+	assert.Contains(t, string(data), "aws_session_token = session\n")
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	// Sections written by hand are kept and never replaced, and the expiry
+	// comment is written again after reading the file
+	require.NoError(t, os.WriteFile(path, append([]byte("[manual]\naws_access_key_id = AKIAMANUAL\n\n"), data...), 0600))
+	file, err = LoadCredentialsFile(path)
+	require.NoError(t, err)
+	assert.ErrorContains(t, file.SetCredentials("manual", &RoleCredentials{AccessKeyID: "ASIA"}), "profile manual in "+path+" was not written by aws-sso-config")
+	require.NoError(t, file.SetCredentials("prod", &RoleCredentials{AccessKeyID: "ASIANEW", Expiration: expiration}))
+	require.NoError(t, file.Save())
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[manual]\naws_access_key_id = AKIAMANUAL\n")
+	assert.Contains(t, string(data), "# Written by aws-sso-config, expires at 2030-01-02T03:04:05Z\n[prod]\n")
+	assert.Contains(t, string(data), "aws_access_key_id = ASIANEW\n")
+}
+
+func TestCredentialsFileKeepsUserLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	require.NoError(t, os.WriteFile(path, []byte(`# Keys for the build machine, rotate monthly
+[manual]
+; legacy user
+aws_access_key_id = AKIAMANUAL
+aws_secret_access_key = manual-secret
+
+# Written by aws-sso-config, expires at 2020-01-01T00:00:00Z
+[expired]
+aws_access_key_id = ASIAEXPIRED
+expiration = 2020-01-01T00:00:00Z
+generated_by = aws-sso-config
+
+# Written by aws-sso-config, expires at 2020-01-01T00:00:00Z
+[prod]
+aws_access_key_id = ASIAOLD
+expiration = 2020-01-01T00:00:00Z
+generated_by = aws-sso-config
+
+# Ask the platform team before removing
+[other]
+aws_access_key_id = AKIAOTHER
+`), 0600))
+
+	file, err := LoadCredentialsFile(path)
+	require.NoError(t, err)
+	expiration := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, file.SetCredentials("prod", &RoleCredentials{AccessKeyID: "ASIANEW", SecretAccessKey: "secret", SessionToken: "session", Expiration: expiration}))
+	require.NoError(t, file.SetCredentials("dev", &RoleCredentials{AccessKeyID: "ASIADEV", SecretAccessKey: "secret", SessionToken: "session", Expiration: expiration}))
+	assert.Equal(t, []string{"expired"}, file.RemoveExpired(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+	require.NoError(t, file.Save())
+
+	// Only the sections aws-sso-config wrote change, in place, and new sections are
+	// added at the end
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `# Keys for the build machine, rotate monthly
+[manual]
+; legacy user
+aws_access_key_id = AKIAMANUAL
+aws_secret_access_key = manual-secret
+
+# Written by aws-sso-config, expires at 2030-01-02T03:04:05Z
+[prod]
+aws_access_key_id = ASIANEW
+aws_secret_access_key = secret
+aws_session_token = session
+expiration = 2030-01-02T03:04:05Z
+generated_by = aws-sso-config
+
+# Ask the platform team before removing
+[other]
+aws_access_key_id = AKIAOTHER
+
+# Written by aws-sso-config, expires at 2030-01-02T03:04:05Z
+[dev]
+aws_access_key_id = ASIADEV
+aws_secret_access_key = secret
+aws_session_token = session
+expiration = 2030-01-02T03:04:05Z
+generated_by = aws-sso-config
+`, string(data))
+}
+
+func TestCredentialsFileRemoveExpired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	require.NoError(t, os.WriteFile(path, []byte(`[manual]
+aws_access_key_id = AKIAMANUAL
+
+[expired]
+aws_access_key_id = ASIAEXPIRED
+expiration = 2020-01-01T00:00:00Z
+generated_by = aws-sso-config
+
+[valid]
+aws_access_key_id = ASIAVALID
+expiration = 2030-01-01T00:00:00Z
+generated_by = aws-sso-config
+
+[no-expiry]
+aws_access_key_id = ASIANOEXPIRY
+generated_by = aws-sso-config
+`), 0600))
+
+	file, err := LoadCredentialsFile(path)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"expired", "no-expiry"}, file.RemoveExpired(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+	require.NoError(t, file.Save())
+
+	file, err = LoadCredentialsFile(path)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"manual", "valid"}, file.parser.Sections())
+
+	// Nothing else has expired
+	assert.Empty(t, file.RemoveExpired(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
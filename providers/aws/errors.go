@@ -0,0 +1,31 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned when logging in to AWS SSO, so that callers can tell a user
+// stopping the login from a failure to reach the service
+var (
+	// ErrLoginCanceled is returned when the user stops waiting for the login, e.g. with Ctrl-C
+	ErrLoginCanceled = errors.New("login canceled")
+	// ErrAuthorizationExpired is returned when the login is not approved before it expires
+	ErrAuthorizationExpired = errors.New("authorization timed out, please try again")
+	// ErrAuthorizationDenied is returned when the user denies the login
+	ErrAuthorizationDenied = errors.New("authorization was denied")
+	// ErrStateMismatch is reported to login redirects which do not belong to the login request
+	ErrStateMismatch = errors.New("authorization response does not match the login request")
+)
+
+// LoginErrorMessage returns the message shown when logging in to AWS SSO fails
+func LoginErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrLoginCanceled):
+		return "Login canceled"
+	case errors.Is(err, ErrAuthorizationExpired), errors.Is(err, ErrAuthorizationDenied):
+		return fmt.Sprintf("Login failed: %v", err)
+	default:
+		return fmt.Sprintf("Error logging in to AWS SSO: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// displayAvailable reports whether a browser can be opened for the user. Tests
+// replace it to avoid depending on the environment they run in.
+var displayAvailable = func() bool {
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		// A browser would open on the remote machine's screen, not the user's
+		return os.Getenv("SSH_CONNECTION") == "" && os.Getenv("SSH_TTY") == ""
+	default:
+		return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+	}
+}
+
+// headless reports whether the login prints the verification URL and code
+// instead of opening a browser
+func headless(appCfg *appconfig.Config) bool {
+	return appCfg.Login.Headless || !displayAvailable()
+}
+
+// startLogin sends the user to the device authorization page. The page is opened
+// with the opener unless the login is headless or the opener fails, in which case
+// the verification URL and user code are shown for the user to enter on any device.
+func startLogin(ui cli.Ui, appCfg *appconfig.Config, deviceAuth *ssooidc.StartDeviceAuthorizationOutput, opener func(string) error) {
+	if headless(appCfg) {
+		showUserCode(ui, deviceAuth)
+		return
+	}
+
+	url := aws.ToString(deviceAuth.VerificationUriComplete)
+	ui.Output(fmt.Sprintf("Opening browser for AWS SSO login...\n%v", url))
+	if err := opener(url); err != nil {
+		ui.Warn("Failed to open browser automatically.")
+		showUserCode(ui, deviceAuth)
+	}
+}
+
+// loginWithDeviceCode logs in with the device authorization grant: the user enters
+// or confirms a code on the AWS login page while the service is polled for the token
+func loginWithDeviceCode(
+	ctx context.Context,
+	ui cli.Ui,
+	client SSOOIDCClient,
+	appCfg *appconfig.Config,
+	register *ssooidc.RegisterClientOutput,
+	opener func(string) error,
+) (*ssooidc.CreateTokenOutput, error) {
+	deviceAuth, err := client.StartDeviceAuthorization(ctx, &ssooidc.StartDeviceAuthorizationInput{
+		ClientId:     register.ClientId,
+		ClientSecret: register.ClientSecret,
+		StartUrl:     aws.String(appCfg.SSOStartURL()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start device authorization: %w", err)
+	}
+
+	startLogin(ui, appCfg, deviceAuth, opener)
+	ui.Output("Waiting for authorization... (this may take a few moments)")
+
+	return pollForToken(ctx, ui, client, register, deviceAuth)
+}
+
+// interactiveLogin logs the user in with the configured login flow
+func interactiveLogin(
+	ctx context.Context,
+	ui cli.Ui,
+	client SSOOIDCClient,
+	appCfg *appconfig.Config,
+	register *ssooidc.RegisterClientOutput,
+	opener func(string) error,
+) (*ssooidc.CreateTokenOutput, error) {
+	if authCodeFlow(appCfg) {
+		return loginWithAuthCode(ctx, ui, client, appCfg, register, opener)
+	}
+	return loginWithDeviceCode(ctx, ui, client, appCfg, register, opener)
+}
+
+// showUserCode shows the verification URL and the user code to enter there
+func showUserCode(ui cli.Ui, deviceAuth *ssooidc.StartDeviceAuthorizationOutput) {
+	url := aws.ToString(deviceAuth.VerificationUri)
+	if url == "" {
+		url = aws.ToString(deviceAuth.VerificationUriComplete)
+	}
+	message := fmt.Sprintf("\nTo log in to AWS SSO, open this URL in a browser on any device:\n\n    %s\n", url)
+	if code := aws.ToString(deviceAuth.UserCode); code != "" {
+		message += fmt.Sprintf("\nand enter the code:\n\n    %s\n", code)
+	}
+	ui.Output(message)
+}
@@ -0,0 +1,97 @@
+package aws
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// withDisplay sets whether a display is available for the test
+func withDisplay(t *testing.T, available bool) {
+	t.Helper()
+	original := displayAvailable
+	displayAvailable = func() bool { return available }
+	t.Cleanup(func() { displayAvailable = original })
+}
+
+func testDeviceAuth() *ssooidc.StartDeviceAuthorizationOutput {
+	return &ssooidc.StartDeviceAuthorizationOutput{
+		UserCode:                aws.String("ABCD-EFGH"),
+		VerificationUri:         aws.String("https://device.sso.us-west-2.amazonaws.com/"),
+		VerificationUriComplete: aws.String("https://device.sso.us-west-2.amazonaws.com/?user_code=ABCD-EFGH"),
+	}
+}
+
+func TestHeadless(t *testing.T) {
+	tests := []struct {
+		name     string
+		headless bool
+		display  bool
+		expected bool
+	}{
+		{"display available", false, true, false},
+		{"configured headless", true, true, true},
+		{"no display", false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withDisplay(t, tt.display)
+			appCfg := appconfig.Default()
+			appCfg.Login.Headless = tt.headless
+			assert.Equal(t, tt.expected, headless(appCfg))
+		})
+	}
+}
+
+func TestStartLogin(t *testing.T) {
+	withDisplay(t, true)
+	appCfg := appconfig.Default()
+
+	var opened []string
+	opener := func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+
+	startLogin(cli.NewMockUi(), appCfg, testDeviceAuth(), opener)
+	assert.Equal(t, []string{"https://device.sso.us-west-2.amazonaws.com/?user_code=ABCD-EFGH"}, opened)
+
+	// Headless logins never open a browser
+	appCfg.Login.Headless = true
+	startLogin(cli.NewMockUi(), appCfg, testDeviceAuth(), opener)
+	assert.Len(t, opened, 1)
+
+	// Nor do logins without a display
+	appCfg.Login.Headless = false
+	withDisplay(t, false)
+	startLogin(cli.NewMockUi(), appCfg, testDeviceAuth(), func(string) error {
+		t.Fatal("browser should not be opened")
+		return errors.New("unreachable")
+	})
+}
+
+func TestShowUserCode(t *testing.T) {
+	ui := cli.NewMockUi()
+	showUserCode(ui, testDeviceAuth())
+
+	// The short URL is shown with the code to enter there
+	output := ui.OutputWriter.String()
+	assert.Contains(t, output, "    https://device.sso.us-west-2.amazonaws.com/\n")
+	assert.Contains(t, output, "    ABCD-EFGH\n")
+	assert.NotContains(t, output, "user_code=")
+
+	// Without a separate URL the complete one is shown
+	ui = cli.NewMockUi()
+	showUserCode(ui, &ssooidc.StartDeviceAuthorizationOutput{
+		VerificationUriComplete: aws.String("https://device.sso.us-west-2.amazonaws.com/?user_code=ABCD-EFGH"),
+	})
+	assert.Contains(t, ui.OutputWriter.String(), "user_code=ABCD-EFGH")
+	assert.NotContains(t, ui.OutputWriter.String(), "enter the code")
+}
@@ -0,0 +1,103 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// SSOLogoutClient is the SSO operation used to sign out, allowing mocking in tests
+type SSOLogoutClient interface {
+	Logout(ctx context.Context, params *sso.LogoutInput, optFns ...func(*sso.Options)) (*sso.LogoutOutput, error)
+}
+
+// Logout signs the cached tokens for the configured SSO portal out of the portal
+// and removes them from the SSO cache, together with the cached client
+// registrations of both login flows and the cached role credentials. A token the
+// service fails to sign out is still removed, with a warning. It reports whether
+// anything was removed.
+func Logout(ctx context.Context, ui cli.Ui, client SSOLogoutClient, appCfg *appconfig.Config) (bool, error) {
+	tokenFiles, err := cachedTokenFiles(appCfg)
+	if err != nil {
+		return false, err
+	}
+
+	removed := false
+	revoked := make(map[string]bool)
+	for path, entry := range tokenFiles {
+		if entry.validAt(time.Now(), 0) && !revoked[entry.AccessToken] {
+			revoked[entry.AccessToken] = true
+			if _, err := client.Logout(ctx, &sso.LogoutInput{AccessToken: aws.String(entry.AccessToken)}); err != nil {
+				ui.Warn(fmt.Sprintf("Failed to sign out of AWS SSO, removing the cached token anyway: %v", err))
+			}
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		removed = true
+	}
+
+	// Both registrations are removed whichever flow is configured or available
+	for _, input := range []*ssooidc.RegisterClientInput{deviceCodeRegisterClientInput(appCfg), authCodeRegisterClientInput(appCfg)} {
+		path, err := registrationCachePath(appCfg, input)
+		if err != nil {
+			return removed, err
+		}
+		if err := os.Remove(path); err == nil {
+			removed = true
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+
+	removedCredentials, err := removeRoleCredentials(appCfg.SSOStartURL())
+	return removed || removedCredentials, err
+}
+
+// cachedTokenFiles returns the token cache files for the configured SSO portal,
+// whether or not their tokens have expired
+func cachedTokenFiles(appCfg *appconfig.Config) (map[string]*SSOCacheEntry, error) {
+	dir, err := ssoCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	files := make(map[string]*SSOCacheEntry)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var cacheEntry SSOCacheEntry
+		if err := json.Unmarshal(data, &cacheEntry); err != nil || cacheEntry.AccessToken == "" {
+			continue
+		}
+		if cacheEntry.matches(appCfg) {
+			files[path] = &cacheEntry
+		}
+	}
+	return files, nil
+}
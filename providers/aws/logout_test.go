@@ -0,0 +1,123 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock implementation of SSOLogoutClient interface for testing
+type MockSSOLogoutClient struct {
+	mock.Mock
+}
+
+func (m *MockSSOLogoutClient) Logout(ctx context.Context, params *sso.LogoutInput, optFns ...func(*sso.Options)) (*sso.LogoutOutput, error) {
+	args := m.Called(ctx, params, optFns)
+	result := args.Get(0)
+	if result == nil {
+		return nil, args.Error(1)
+	}
+	return result.(*sso.LogoutOutput), args.Error(1)
+}
+
+func TestLogout(t *testing.T) {
+	appCfg, dir := testCacheConfig(t)
+	require.NoError(t, writeCachedToken(appCfg, SSOCacheEntry{
+		AccessToken: "valid-token",
+		ExpiresAt:   time.Now().Add(time.Hour),
+		StartURL:    appCfg.SSOStartURL(),
+		Region:      appCfg.SSORegion(),
+	}))
+	// An expired token for the same portal, written by another tool
+	expired := filepath.Join(dir, "other.json")
+	require.NoError(t, os.WriteFile(expired, []byte(`{"accessToken":"expired-token","expiresAt":"2020-01-01T00:00:00Z","startUrl":"`+appCfg.SSOStartURL()+`"}`), 0600))
+	// A token for another portal is kept
+	other := filepath.Join(dir, "another-portal.json")
+	require.NoError(t, os.WriteFile(other, []byte(`{"accessToken":"other-token","expiresAt":"2099-01-01T00:00:00Z","startUrl":"https://other.awsapps.com/start"}`), 0600))
+
+	// Registrations of both login flows are removed, even without a display to
+	// log in with PKCE
+	withDisplay(t, false)
+	input := deviceCodeRegisterClientInput(appCfg)
+	register := &ssooidc.RegisterClientOutput{
+		ClientId:              aws.String("client-id"),
+		ClientSecret:          aws.String("client-secret"),
+		ClientSecretExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
+	}
+	require.NoError(t, writeRegistration(appCfg, input, register))
+	pkceInput := authCodeRegisterClientInput(appCfg)
+	require.NoError(t, writeRegistration(appCfg, pkceInput, register))
+	require.NotNil(t, readRegistration(appCfg, pkceInput, time.Now()))
+
+	// So are role credentials cached for the portal
+	profile := &SSOProfile{AccountID: "123456789012", RoleName: "Admin", StartURL: appCfg.SSOStartURL()}
+	fetch, _ := countingFetch(time.Hour)
+	_, err := cachedRoleCredentials(cli.NewMockUi(), profile, fetch)
+	require.NoError(t, err)
+	credentialsPath, err := roleCredentialsCachePath(profile)
+	require.NoError(t, err)
+
+	// Only the valid token is signed out
+	mockClient := new(MockSSOLogoutClient)
+	mockClient.On("Logout", mock.Anything, mock.MatchedBy(func(input *sso.LogoutInput) bool {
+		return aws.ToString(input.AccessToken) == "valid-token"
+	}), mock.Anything).Return(&sso.LogoutOutput{}, nil).Once()
+
+	removed, err := Logout(context.Background(), cli.NewMockUi(), mockClient, appCfg)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	mockClient.AssertExpectations(t)
+
+	_, err = readCachedToken(appCfg)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.NoFileExists(t, expired)
+	assert.FileExists(t, other)
+	assert.Nil(t, readRegistration(appCfg, input, time.Now()))
+	assert.Nil(t, readRegistration(appCfg, pkceInput, time.Now()))
+	assert.NoFileExists(t, credentialsPath)
+
+	// Logging out again has nothing to remove
+	removed, err = Logout(context.Background(), cli.NewMockUi(), mockClient, appCfg)
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestLogoutSignOutFailure(t *testing.T) {
+	appCfg, _ := testCacheConfig(t)
+	require.NoError(t, writeCachedToken(appCfg, SSOCacheEntry{
+		AccessToken: "valid-token",
+		ExpiresAt:   time.Now().Add(time.Hour),
+		StartURL:    appCfg.SSOStartURL(),
+	}))
+
+	mockClient := new(MockSSOLogoutClient)
+	mockClient.On("Logout", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("network error"))
+
+	// The token is removed even though it could not be signed out
+	ui := cli.NewMockUi()
+	removed, err := Logout(context.Background(), ui, mockClient, appCfg)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	assert.Contains(t, ui.ErrorWriter.String(), "network error")
+	assert.Nil(t, getCurrentToken(appCfg))
+}
+
+func TestLogoutNoCache(t *testing.T) {
+	appCfg, _ := testCacheConfig(t)
+	t.Setenv("AWS_SSO_CACHE_PATH", filepath.Join(t.TempDir(), "missing"))
+
+	removed, err := Logout(context.Background(), cli.NewMockUi(), new(MockSSOLogoutClient), appCfg)
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
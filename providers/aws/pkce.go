@@ -0,0 +1,183 @@
+package aws
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+const (
+	grantTypeAuthorizationCode = "authorization_code"
+	// callbackPath is where the browser is redirected with the authorization code
+	callbackPath = "/oauth/callback"
+	// registeredRedirectURI is the redirect URI the client is registered with. Loopback
+	// redirects may use any port (RFC 8252), so the listener's port is added when logging in.
+	registeredRedirectURI = "http://127.0.0.1" + callbackPath
+	// authCodeTimeout is how long to wait for the browser to be redirected back
+	authCodeTimeout = 10 * time.Minute
+)
+
+// authCodeFlow reports whether the login uses the authorization code grant with
+// PKCE. The browser has to run on this machine to reach the loopback listener, so
+// headless logins use the device code instead.
+func authCodeFlow(appCfg *appconfig.Config) bool {
+	return appCfg.Login.Flow == appconfig.LoginFlowPKCE && !headless(appCfg)
+}
+
+// authorizeEndpoint returns the OIDC authorization endpoint for the region
+func authorizeEndpoint(region string) string {
+	return fmt.Sprintf("https://oidc.%s.amazonaws.com/authorize", region)
+}
+
+// randomString returns a URL safe string encoding n random bytes
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallenge returns the S256 PKCE code challenge for the verifier
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// callbackResult is the outcome of the redirect back to the loopback listener
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// callbackHandler receives the redirect back from the authorization endpoint and
+// sends the authorization code, or the reason there is none, to results. Requests
+// for another state are rejected without ending the login, so that a stray request
+// to the listener cannot cancel it.
+func callbackHandler(state string, results chan<- callbackResult) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if query.Get("state") != state {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "AWS SSO login failed: %v\n", ErrStateMismatch)
+			return
+		}
+
+		var result callbackResult
+		switch {
+		case query.Get("error") == "access_denied":
+			result.err = ErrAuthorizationDenied
+		case query.Get("error") != "":
+			result.err = fmt.Errorf("authorization failed: %s %s", query.Get("error"), query.Get("error_description"))
+		case query.Get("code") == "":
+			result.err = errors.New("authorization response is missing the code")
+		default:
+			result.code = query.Get("code")
+		}
+
+		if result.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "AWS SSO login failed: %v\n", result.err)
+		} else {
+			fmt.Fprintln(w, "AWS SSO login complete. You can close this window and return to the terminal.")
+		}
+
+		// Only the first redirect is used
+		select {
+		case results <- result:
+		default:
+		}
+	})
+	return mux
+}
+
+// loginWithAuthCode logs in with the authorization code grant and PKCE. The browser
+// is sent to the authorization endpoint and redirected back to a listener on
+// 127.0.0.1 with a code, which is exchanged for a token.
+func loginWithAuthCode(
+	ctx context.Context,
+	ui cli.Ui,
+	client SSOOIDCClient,
+	appCfg *appconfig.Config,
+	register *ssooidc.RegisterClientOutput,
+	opener func(string) error,
+) (*ssooidc.CreateTokenOutput, error) {
+	verifier, err := randomString(64)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the login redirect: %w", err)
+	}
+	redirectURI := fmt.Sprintf("http://%s%s", listener.Addr(), callbackPath)
+
+	results := make(chan callbackResult, 1)
+	server := &http.Server{Handler: callbackHandler(state, results), ReadHeaderTimeout: 10 * time.Second}
+	// Serve returns when the server is closed
+	go server.Serve(listener)
+	defer server.Close()
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {aws.ToString(register.ClientId)},
+		"redirect_uri":          {redirectURI},
+		"state":                 {state},
+		"code_challenge_method": {"S256"},
+		"code_challenge":        {codeChallenge(verifier)},
+		"scopes":                {strings.Join(registrationScopes(appCfg), " ")},
+	}
+	authorizeURL := authorizeEndpoint(appCfg.SSORegion()) + "?" + query.Encode()
+	ui.Output(fmt.Sprintf("Opening browser for AWS SSO login...\n%v", authorizeURL))
+	if err := opener(authorizeURL); err != nil {
+		ui.Warn("Failed to open browser automatically. Please open the URL above in a browser on this machine.")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, authCodeTimeout)
+	defer cancel()
+
+	var result callbackResult
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return nil, pollError(ctx)
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	token, err := client.CreateToken(ctx, &ssooidc.CreateTokenInput{
+		ClientId:     register.ClientId,
+		ClientSecret: register.ClientSecret,
+		GrantType:    aws.String(grantTypeAuthorizationCode),
+		Code:         aws.String(result.code),
+		CodeVerifier: aws.String(verifier),
+		RedirectUri:  aws.String(redirectURI),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+	ui.Output("✓ Authorization successful!")
+	return token, nil
+}
@@ -0,0 +1,209 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// testAuthCodeConfig returns an app config using the PKCE login flow
+func testAuthCodeConfig(t *testing.T) *appconfig.Config {
+	t.Helper()
+	appCfg, _ := testCacheConfig(t)
+	appCfg.Login.Flow = appconfig.LoginFlowPKCE
+	withDisplay(t, true)
+	return appCfg
+}
+
+// redirect follows the redirect back to the CLI with the query, as the browser would
+func redirect(t *testing.T, authorizeURL string, query url.Values) *http.Response {
+	t.Helper()
+	parsed, err := url.Parse(authorizeURL)
+	require.NoError(t, err)
+
+	resp, err := http.Get(parsed.Query().Get("redirect_uri") + "?" + query.Encode())
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+func TestCodeChallenge(t *testing.T) {
+	assert.Equal(t, "JBbiqONGWPaAmwXk_8bT6UnlPfrn65D32eZlJS-zGG0", codeChallenge("test-verifier"))
+
+	verifier, err := randomString(64)
+	require.NoError(t, err)
+	// Verifiers must be 43 to 128 characters (RFC 7636)
+	assert.Len(t, verifier, 86)
+}
+
+func TestAuthCodeFlow(t *testing.T) {
+	appCfg := testAuthCodeConfig(t)
+	assert.True(t, authCodeFlow(appCfg))
+
+	input := registerClientInput(appCfg)
+	assert.Equal(t, []string{grantTypeAuthorizationCode, grantTypeRefreshToken}, input.GrantTypes)
+	assert.Equal(t, []string{"http://127.0.0.1/oauth/callback"}, input.RedirectUris)
+	assert.Equal(t, appCfg.SSOStartURL(), aws.ToString(input.IssuerUrl))
+
+	// Headless logins use the device code
+	appCfg.Login.Headless = true
+	assert.False(t, authCodeFlow(appCfg))
+	assert.Equal(t, []string{grantTypeDeviceCode, grantTypeRefreshToken}, registerClientInput(appCfg).GrantTypes)
+
+	appCfg.Login.Headless = false
+	appCfg.Login.Flow = appconfig.LoginFlowDeviceCode
+	assert.False(t, authCodeFlow(appCfg))
+}
+
+func TestLoginWithAuthCode(t *testing.T) {
+	appCfg := testAuthCodeConfig(t)
+	register := &ssooidc.RegisterClientOutput{ClientId: aws.String("client-id"), ClientSecret: aws.String("client-secret")}
+
+	var authorize url.Values
+	opener := func(authorizeURL string) error {
+		parsed, err := url.Parse(authorizeURL)
+		require.NoError(t, err)
+		assert.Equal(t, "https://oidc.us-west-2.amazonaws.com/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+		authorize = parsed.Query()
+
+		resp := redirect(t, authorizeURL, url.Values{"code": {"auth-code"}, "state": {authorize.Get("state")}})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		return nil
+	}
+
+	mockClient := new(MockSSOOIDCClient)
+	mockClient.On("CreateToken", mock.Anything, mock.MatchedBy(func(input *ssooidc.CreateTokenInput) bool {
+		return aws.ToString(input.GrantType) == grantTypeAuthorizationCode &&
+			aws.ToString(input.Code) == "auth-code" &&
+			aws.ToString(input.RedirectUri) == authorize.Get("redirect_uri") &&
+			codeChallenge(aws.ToString(input.CodeVerifier)) == authorize.Get("code_challenge")
+	}), mock.Anything).Return(&ssooidc.CreateTokenOutput{AccessToken: aws.String("token")}, nil).Once()
+
+	token, err := loginWithAuthCode(context.Background(), cli.NewMockUi(), mockClient, appCfg, register, opener)
+	require.NoError(t, err)
+	assert.Equal(t, "token", aws.ToString(token.AccessToken))
+	mockClient.AssertExpectations(t)
+
+	assert.Equal(t, "code", authorize.Get("response_type"))
+	assert.Equal(t, "client-id", authorize.Get("client_id"))
+	assert.Equal(t, "S256", authorize.Get("code_challenge_method"))
+	assert.Equal(t, "sso:account:access", authorize.Get("scopes"))
+	assert.Regexp(t, `^http://127\.0\.0\.1:\d+/oauth/callback$`, authorize.Get("redirect_uri"))
+	assert.NotEmpty(t, authorize.Get("state"))
+}
+
+func TestLoginWithAuthCodeRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    func(state string) url.Values
+		expected string
+	}{
+		{
+			name: "access denied",
+			query: func(state string) url.Values {
+				return url.Values{"error": {"access_denied"}, "error_description": {"denied"}, "state": {state}}
+			},
+			expected: ErrAuthorizationDenied.Error(),
+		},
+		{
+			name: "server error",
+			query: func(state string) url.Values {
+				return url.Values{"error": {"server_error"}, "error_description": {"unavailable"}, "state": {state}}
+			},
+			expected: "authorization failed: server_error unavailable",
+		},
+		{
+			name:     "missing code",
+			query:    func(state string) url.Values { return url.Values{"state": {state}} },
+			expected: "missing the code",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appCfg := testAuthCodeConfig(t)
+			opener := func(authorizeURL string) error {
+				parsed, err := url.Parse(authorizeURL)
+				require.NoError(t, err)
+				resp := redirect(t, authorizeURL, tt.query(parsed.Query().Get("state")))
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+				return nil
+			}
+
+			// The code is never exchanged
+			mockClient := new(MockSSOOIDCClient)
+			_, err := loginWithAuthCode(context.Background(), cli.NewMockUi(), mockClient, appCfg, &ssooidc.RegisterClientOutput{}, opener)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+			mockClient.AssertNotCalled(t, "CreateToken", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestLoginWithAuthCodeStateMismatch(t *testing.T) {
+	appCfg := testAuthCodeConfig(t)
+
+	// A redirect for another login is rejected and the real redirect is still used
+	opener := func(authorizeURL string) error {
+		parsed, err := url.Parse(authorizeURL)
+		require.NoError(t, err)
+		resp := redirect(t, authorizeURL, url.Values{"code": {"forged-code"}, "state": {"forged"}})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = redirect(t, authorizeURL, url.Values{"code": {"auth-code"}, "state": {parsed.Query().Get("state")}})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		return nil
+	}
+
+	mockClient := new(MockSSOOIDCClient)
+	mockClient.On("CreateToken", mock.Anything, mock.MatchedBy(func(input *ssooidc.CreateTokenInput) bool {
+		return aws.ToString(input.Code) == "auth-code"
+	}), mock.Anything).Return(&ssooidc.CreateTokenOutput{AccessToken: aws.String("token")}, nil).Once()
+
+	token, err := loginWithAuthCode(context.Background(), cli.NewMockUi(), mockClient, appCfg, &ssooidc.RegisterClientOutput{}, opener)
+	require.NoError(t, err)
+	assert.Equal(t, "token", aws.ToString(token.AccessToken))
+	mockClient.AssertExpectations(t)
+}
+
+func TestLoginWithAuthCodeWaitsAfterStateMismatch(t *testing.T) {
+	appCfg := testAuthCodeConfig(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Only a forged redirect arrives, so the login waits until it is stopped
+	opener := func(authorizeURL string) error {
+		resp := redirect(t, authorizeURL, url.Values{"code": {"forged-code"}, "state": {"forged"}})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		cancel()
+		return nil
+	}
+
+	mockClient := new(MockSSOOIDCClient)
+	_, err := loginWithAuthCode(ctx, cli.NewMockUi(), mockClient, appCfg, &ssooidc.RegisterClientOutput{}, opener)
+	assert.ErrorIs(t, err, ErrLoginCanceled)
+	mockClient.AssertNotCalled(t, "CreateToken", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLoginWithAuthCodeCanceled(t *testing.T) {
+	appCfg := testAuthCodeConfig(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// The browser never redirects back before the user presses Ctrl-C
+	opener := func(string) error {
+		cancel()
+		return nil
+	}
+
+	_, err := loginWithAuthCode(ctx, cli.NewMockUi(), new(MockSSOOIDCClient), appCfg, &ssooidc.RegisterClientOutput{}, opener)
+	assert.ErrorIs(t, err, ErrLoginCanceled)
+}
@@ -0,0 +1,107 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc/types"
+	"github.com/mitchellh/cli"
+)
+
+// Device flow polling defaults, used when the service does not return them (RFC 8628)
+const (
+	defaultPollInterval     = 5 * time.Second
+	defaultDeviceCodeExpiry = 10 * time.Minute
+	slowDownIncrement       = 5 * time.Second
+	pollStatusInterval      = 30 * time.Second
+)
+
+// sleep waits for d, returning early with the context's error when it is done.
+// Tests replace it to avoid waiting.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// pollForToken waits for the user to authorize the device and returns the created
+// token. The service is polled at the interval it asked for, more slowly each time
+// it asks to slow down, until the device code expires or the context is canceled.
+func pollForToken(
+	ctx context.Context,
+	ui cli.Ui,
+	client SSOOIDCClient,
+	register *ssooidc.RegisterClientOutput,
+	deviceAuth *ssooidc.StartDeviceAuthorizationOutput,
+) (*ssooidc.CreateTokenOutput, error) {
+	interval := time.Duration(deviceAuth.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	expiresIn := time.Duration(deviceAuth.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = defaultDeviceCodeExpiry
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, expiresIn)
+	defer cancel()
+
+	input := &ssooidc.CreateTokenInput{
+		ClientId:     register.ClientId,
+		ClientSecret: register.ClientSecret,
+		DeviceCode:   deviceAuth.DeviceCode,
+		GrantType:    aws.String(grantTypeDeviceCode),
+	}
+	start, lastStatus := time.Now(), time.Now()
+
+	for {
+		token, err := client.CreateToken(ctx, input)
+		if err == nil {
+			ui.Output("✓ Authorization successful!")
+			return token, nil
+		}
+
+		var pending *types.AuthorizationPendingException
+		var slowDown *types.SlowDownException
+		var expired *types.ExpiredTokenException
+		var denied *types.AccessDeniedException
+		switch {
+		case errors.As(err, &slowDown):
+			interval += slowDownIncrement
+		case errors.As(err, &expired):
+			return nil, ErrAuthorizationExpired
+		case errors.As(err, &denied):
+			return nil, ErrAuthorizationDenied
+		case ctx.Err() != nil:
+			return nil, pollError(ctx)
+		case !errors.As(err, &pending):
+			return nil, fmt.Errorf("failed to create token: %w", err)
+		}
+
+		if time.Since(lastStatus) >= pollStatusInterval {
+			ui.Output(fmt.Sprintf("Still waiting for authorization... (%s elapsed)", time.Since(start).Round(time.Second)))
+			lastStatus = time.Now()
+		}
+		if err := sleep(ctx, interval); err != nil {
+			return nil, pollError(ctx)
+		}
+	}
+}
+
+// pollError returns the error for polling stopped by the context
+func pollError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrAuthorizationExpired
+	}
+	return ErrLoginCanceled
+}
@@ -0,0 +1,112 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc/types"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSleep replaces sleep for the test and records the requested waits
+func fakeSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	original := sleep
+	sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	t.Cleanup(func() { sleep = original })
+	return &waits
+}
+
+func TestPollForToken(t *testing.T) {
+	waits := fakeSleep(t)
+	register := &ssooidc.RegisterClientOutput{ClientId: aws.String("client-id"), ClientSecret: aws.String("client-secret")}
+	deviceAuth := &ssooidc.StartDeviceAuthorizationOutput{DeviceCode: aws.String("device-code"), Interval: 2, ExpiresIn: 600}
+
+	mockClient := new(MockSSOOIDCClient)
+	mockClient.On("CreateToken", mock.Anything, mock.MatchedBy(func(input *ssooidc.CreateTokenInput) bool {
+		return aws.ToString(input.DeviceCode) == "device-code" && aws.ToString(input.GrantType) == grantTypeDeviceCode
+	}), mock.Anything).Return(nil, &types.AuthorizationPendingException{}).Once()
+	mockClient.On("CreateToken", mock.Anything, mock.Anything, mock.Anything).Return(nil, &types.SlowDownException{}).Once()
+	mockClient.On("CreateToken", mock.Anything, mock.Anything, mock.Anything).Return(nil, &types.AuthorizationPendingException{}).Once()
+	mockClient.On("CreateToken", mock.Anything, mock.Anything, mock.Anything).Return(&ssooidc.CreateTokenOutput{AccessToken: aws.String("token")}, nil).Once()
+
+	token, err := pollForToken(context.Background(), cli.NewMockUi(), mockClient, register, deviceAuth)
+	require.NoError(t, err)
+	assert.Equal(t, "token", aws.ToString(token.AccessToken))
+
+	// The requested interval is used and slowed down when asked
+	assert.Equal(t, []time.Duration{2 * time.Second, 7 * time.Second, 7 * time.Second}, *waits)
+	mockClient.AssertExpectations(t)
+}
+
+func TestPollForTokenDefaultInterval(t *testing.T) {
+	waits := fakeSleep(t)
+
+	mockClient := new(MockSSOOIDCClient)
+	mockClient.On("CreateToken", mock.Anything, mock.Anything, mock.Anything).Return(nil, &types.AuthorizationPendingException{}).Once()
+	mockClient.On("CreateToken", mock.Anything, mock.Anything, mock.Anything).Return(&ssooidc.CreateTokenOutput{AccessToken: aws.String("token")}, nil).Once()
+
+	_, err := pollForToken(context.Background(), cli.NewMockUi(), mockClient, &ssooidc.RegisterClientOutput{}, &ssooidc.StartDeviceAuthorizationOutput{})
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{defaultPollInterval}, *waits)
+}
+
+func TestPollForTokenErrors(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		err      error
+		expected error
+	}{
+		{"device code expired", context.Background(), &types.ExpiredTokenException{}, ErrAuthorizationExpired},
+		{"access denied", context.Background(), &types.AccessDeniedException{}, ErrAuthorizationDenied},
+		{"canceled while pending", canceled, &types.AuthorizationPendingException{}, ErrLoginCanceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeSleep(t)
+			mockClient := new(MockSSOOIDCClient)
+			mockClient.On("CreateToken", mock.Anything, mock.Anything, mock.Anything).Return(nil, tt.err).Once()
+
+			token, err := pollForToken(tt.ctx, cli.NewMockUi(), mockClient, &ssooidc.RegisterClientOutput{}, &ssooidc.StartDeviceAuthorizationOutput{})
+			assert.Nil(t, token)
+			assert.ErrorIs(t, err, tt.expected)
+			mockClient.AssertExpectations(t)
+		})
+	}
+
+	// Other errors are returned wrapped
+	fakeSleep(t)
+	boom := errors.New("boom")
+	mockClient := new(MockSSOOIDCClient)
+	mockClient.On("CreateToken", mock.Anything, mock.Anything, mock.Anything).Return(nil, boom).Once()
+	_, err := pollForToken(context.Background(), cli.NewMockUi(), mockClient, &ssooidc.RegisterClientOutput{}, &ssooidc.StartDeviceAuthorizationOutput{})
+	assert.ErrorIs(t, err, boom)
+}
+
+func TestPollForTokenExpires(t *testing.T) {
+	// Polling stops when the device code expires
+	mockClient := new(MockSSOOIDCClient)
+	mockClient.On("CreateToken", mock.Anything, mock.Anything, mock.Anything).Return(nil, &types.AuthorizationPendingException{})
+
+	deviceAuth := &ssooidc.StartDeviceAuthorizationOutput{Interval: 1, ExpiresIn: 1}
+	start := time.Now()
+	_, err := pollForToken(context.Background(), cli.NewMockUi(), mockClient, &ssooidc.RegisterClientOutput{}, deviceAuth)
+	assert.ErrorIs(t, err, ErrAuthorizationExpired)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
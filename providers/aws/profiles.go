@@ -15,7 +15,7 @@ const AwsProfile = "AWS_PROFILE"
 
 var Logger *log.Logger = log.New(os.Stderr, "", 0)
 
-func validateAccountID(accountID, rootDir string) error {
+func validateAccountID(accountID, rootDir string) (err error) {
 	terragruntFile := filepath.Join(rootDir, "terragrunt.hcl")
 
 	readFile, err := os.Open(terragruntFile)
@@ -24,8 +24,8 @@ func validateAccountID(accountID, rootDir string) error {
 	}
 
 	defer func() {
-		if closeErr := readFile.Close(); closeErr != nil {
-			fmt.Printf("Warning: failed to close file: %v\n", closeErr)
+		if closeErr := readFile.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close %s: %w", terragruntFile, closeErr)
 		}
 	}()
 	fileScanner := bufio.NewScanner(readFile)
@@ -199,7 +199,8 @@ func TestGetProfileWithInvalidGitRepo(t *testing.T) {
 func TestLoadDefaultConfigFunction(t *testing.T) {
 	// This is mainly testing that the function doesn't panic
 	// In a real environment this would load AWS config
-	cfg := LoadDefaultConfig()
+	cfg, err := LoadDefaultConfig()
+	assert.NoError(t, err)
 	assert.NotNil(t, cfg)
 }
 
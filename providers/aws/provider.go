@@ -2,10 +2,12 @@ package aws
 
 import (
 	"context"
-	"time"
+	"os"
+	"os/signal"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
 	"github.com/pkg/browser"
 
 	appconfig "github.com/blairham/aws-sso-config/providers/config"
@@ -24,62 +26,45 @@ type SSOOIDCClient interface {
 
 // Wrapper around AWS functions to facilitate testing
 type AWSProvider struct {
+	UI            cli.Ui
 	SSOOIDCClient SSOOIDCClient
 	BrowserOpener func(string) error
-	TokenPoller   func(client SSOOIDCClient, register *ssooidc.RegisterClientOutput, deviceAuth *ssooidc.StartDeviceAuthorizationOutput) *string
 	Cfg           aws.Config
 }
 
-// Creates a new default AWS provider
-func NewDefaultAWSProvider() *AWSProvider {
-	cfg := LoadDefaultConfig()
-	ssooidcClient := ssooidc.NewFromConfig(cfg)
+// Creates a new default AWS provider for the configured SSO portal which shows
+// login progress on the UI
+func NewDefaultAWSProvider(ui cli.Ui, appCfg *appconfig.Config) (*AWSProvider, error) {
+	cfg, err := LoadDefaultConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return newAWSProvider(ui, cfg, appCfg), nil
+}
 
+// newAWSProvider returns a provider whose OIDC client calls the endpoints in the
+// configured SSO region
+func newAWSProvider(ui cli.Ui, cfg aws.Config, appCfg *appconfig.Config) *AWSProvider {
+	cfg.Region = appCfg.SSORegion()
 	return &AWSProvider{
-		SSOOIDCClient: ssooidcClient,
+		UI:            ui,
+		SSOOIDCClient: ssooidc.NewFromConfig(cfg),
 		BrowserOpener: browser.OpenURL,
-		TokenPoller: func(client SSOOIDCClient, register *ssooidc.RegisterClientOutput, deviceAuth *ssooidc.StartDeviceAuthorizationOutput) *string {
-			// We need to cast the interface to the concrete type
-			if ssoClient, ok := client.(*ssooidc.Client); ok {
-				return pollForToken(ssoClient, register, deviceAuth)
-			}
-			return nil
-		},
-		Cfg: cfg,
+		Cfg:           cfg,
 	}
 }
 
-// Generate token with provider's configuration
-func (p *AWSProvider) GenerateToken(appCfg *appconfig.Config) *string {
-	// create sso oidc client to trigger login flow
-	ssooidcClient := p.SSOOIDCClient
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
-
-	// register your client which is triggering the login flow
-	register, err := ssooidcClient.RegisterClient(ctx, &ssooidc.RegisterClientInput{
-		ClientName: aws.String("aws-sso-config-cli"),
-		ClientType: aws.String("public"),
-		Scopes:     []string{"sso-portal:*"},
-	})
-	if err != nil {
-		return nil
-	}
-
-	// authorize your device using the client registration response
-	deviceAuth, err := ssooidcClient.StartDeviceAuthorization(ctx, &ssooidc.StartDeviceAuthorizationInput{
-		ClientId:     register.ClientId,
-		ClientSecret: register.ClientSecret,
-		StartUrl:     aws.String(appCfg.SSOStartURL()),
-	})
-	if err != nil {
-		return nil
-	}
+// Authenticator returns the authenticator using the provider's client and browser
+func (p *AWSProvider) Authenticator() Authenticator {
+	return NewAuthenticator(p.UI, p.SSOOIDCClient, p.BrowserOpener)
+}
 
-	// trigger OIDC login. open browser to login and wait for authorization
-	url := aws.ToString(deviceAuth.VerificationUriComplete)
-	_ = p.BrowserOpener(url)
+// Generate token with provider's configuration
+func (p *AWSProvider) GenerateToken(appCfg *appconfig.Config) (*string, error) {
+	// Ctrl-C stops waiting for the login
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	return p.TokenPoller(ssooidcClient, register, deviceAuth)
+	return p.Authenticator().Authenticate(ctx, appCfg)
 }
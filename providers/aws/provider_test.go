@@ -7,8 +7,10 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	appconfig "github.com/blairham/aws-sso-config/providers/config"
 )
@@ -54,6 +56,11 @@ func (m *MockSSOOIDCClient) CreateToken(ctx context.Context, params *ssooidc.Cre
 }
 
 func TestAWSProviderGenerateTokenSuccess(t *testing.T) {
+	// Keep cached client registrations out of the user's cache
+	t.Setenv("AWS_SSO_CACHE_PATH", t.TempDir())
+	// The browser is only opened when there is a display
+	withDisplay(t, true)
+
 	// Create mocks and provider
 	mockClient := new(MockSSOOIDCClient)
 
@@ -70,25 +77,25 @@ func TestAWSProviderGenerateTokenSuccess(t *testing.T) {
 	// Mock the client method calls
 	mockClient.On("RegisterClient", mock.Anything, mock.Anything, mock.Anything).Return(mockRegister, nil)
 	mockClient.On("StartDeviceAuthorization", mock.Anything, mock.Anything, mock.Anything).Return(mockDeviceAuth, nil)
+	mockClient.On("CreateToken", mock.Anything, mock.MatchedBy(func(input *ssooidc.CreateTokenInput) bool {
+		return aws.ToString(input.ClientId) == "test-client-id" && aws.ToString(input.DeviceCode) == "test-device-code"
+	}), mock.Anything).Return(&ssooidc.CreateTokenOutput{
+		AccessToken: aws.String("test-access-token"),
+		ExpiresIn:   3600,
+	}, nil)
 
 	// Count browser opens
 	browserOpened := false
 
 	// Setup test provider
 	provider := &AWSProvider{
+		UI:            cli.NewMockUi(),
 		SSOOIDCClient: mockClient,
 		BrowserOpener: func(url string) error {
 			browserOpened = true
 			assert.Equal(t, "https://test-verification-uri.com", url)
 			return nil
 		},
-		TokenPoller: func(client SSOOIDCClient, register *ssooidc.RegisterClientOutput, deviceAuth *ssooidc.StartDeviceAuthorizationOutput) *string {
-			// Verify params passed to token poller are correct
-			assert.Equal(t, mockRegister, register)
-			assert.Equal(t, mockDeviceAuth, deviceAuth)
-			token := "test-access-token"
-			return &token
-		},
 	}
 
 	// Setup test config
@@ -100,18 +107,27 @@ func TestAWSProviderGenerateTokenSuccess(t *testing.T) {
 	}
 
 	// Call the method under test
-	token := provider.GenerateToken(appCfg)
+	token, err := provider.GenerateToken(appCfg)
+	require.NoError(t, err)
 
 	// Verify results
 	assert.NotNil(t, token)
 	assert.Equal(t, "test-access-token", *token)
 	assert.True(t, browserOpened, "Browser should have been opened")
 
+	// The new token is cached for the next run
+	cached := getCurrentToken(appCfg)
+	require.NotNil(t, cached)
+	assert.Equal(t, "test-access-token", *cached)
+
 	// Verify mock calls
 	mockClient.AssertExpectations(t)
 }
 
 func TestAWSProviderGenerateTokenRegisterFailure(t *testing.T) {
+	// Keep cached client registrations out of the user's cache
+	t.Setenv("AWS_SSO_CACHE_PATH", t.TempDir())
+
 	// Create mocks
 	mockClient := new(MockSSOOIDCClient)
 
@@ -121,15 +137,12 @@ func TestAWSProviderGenerateTokenRegisterFailure(t *testing.T) {
 
 	// Setup provider with minimal mocks
 	provider := &AWSProvider{
+		UI:            cli.NewMockUi(),
 		SSOOIDCClient: mockClient,
 		BrowserOpener: func(url string) error {
 			t.Fail() // Should not be called
 			return nil
 		},
-		TokenPoller: func(client SSOOIDCClient, register *ssooidc.RegisterClientOutput, deviceAuth *ssooidc.StartDeviceAuthorizationOutput) *string {
-			t.Fail() // Should not be called
-			return nil
-		},
 	}
 
 	// Setup test config
@@ -141,16 +154,20 @@ func TestAWSProviderGenerateTokenRegisterFailure(t *testing.T) {
 	}
 
 	// Call the method under test
-	token := provider.GenerateToken(appCfg)
+	token, err := provider.GenerateToken(appCfg)
 
 	// Verify results
 	assert.Nil(t, token, "Token should be nil when register client fails")
+	assert.ErrorContains(t, err, "register client error")
 
 	// Verify mock calls
 	mockClient.AssertExpectations(t)
 }
 
 func TestAWSProviderGenerateTokenDeviceAuthFailure(t *testing.T) {
+	// Keep cached client registrations out of the user's cache
+	t.Setenv("AWS_SSO_CACHE_PATH", t.TempDir())
+
 	// Create mocks
 	mockClient := new(MockSSOOIDCClient)
 
@@ -168,15 +185,12 @@ func TestAWSProviderGenerateTokenDeviceAuthFailure(t *testing.T) {
 
 	// Setup provider
 	provider := &AWSProvider{
+		UI:            cli.NewMockUi(),
 		SSOOIDCClient: mockClient,
 		BrowserOpener: func(url string) error {
 			t.Fail() // Should not be called
 			return nil
 		},
-		TokenPoller: func(client SSOOIDCClient, register *ssooidc.RegisterClientOutput, deviceAuth *ssooidc.StartDeviceAuthorizationOutput) *string {
-			t.Fail() // Should not be called
-			return nil
-		},
 	}
 
 	// Setup test config
@@ -188,16 +202,20 @@ func TestAWSProviderGenerateTokenDeviceAuthFailure(t *testing.T) {
 	}
 
 	// Call the method under test
-	token := provider.GenerateToken(appCfg)
+	token, err := provider.GenerateToken(appCfg)
 
 	// Verify results
 	assert.Nil(t, token, "Token should be nil when device auth fails")
+	assert.ErrorContains(t, err, "failed to start device authorization")
 
 	// Verify mock calls
 	mockClient.AssertExpectations(t)
 }
 
 func TestAWSProviderGenerateTokenBrowserOpenFailure(t *testing.T) {
+	// Keep cached client registrations out of the user's cache
+	t.Setenv("AWS_SSO_CACHE_PATH", t.TempDir())
+
 	// Create mocks
 	mockClient := new(MockSSOOIDCClient)
 
@@ -216,18 +234,16 @@ func TestAWSProviderGenerateTokenBrowserOpenFailure(t *testing.T) {
 		Return(mockRegister, nil)
 	mockClient.On("StartDeviceAuthorization", mock.Anything, mock.Anything, mock.Anything).
 		Return(mockDeviceAuth, nil)
+	mockClient.On("CreateToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(&ssooidc.CreateTokenOutput{AccessToken: aws.String("test-access-token")}, nil)
 
 	// Setup provider with browser open failure
 	provider := &AWSProvider{
+		UI:            cli.NewMockUi(),
 		SSOOIDCClient: mockClient,
 		BrowserOpener: func(url string) error {
 			return errors.New("browser open error")
 		},
-		TokenPoller: func(client SSOOIDCClient, register *ssooidc.RegisterClientOutput, deviceAuth *ssooidc.StartDeviceAuthorizationOutput) *string {
-			// Should still be called even if browser fails
-			token := "test-access-token"
-			return &token
-		},
 	}
 
 	// Setup test config
@@ -239,7 +255,8 @@ func TestAWSProviderGenerateTokenBrowserOpenFailure(t *testing.T) {
 	}
 
 	// Call the method under test
-	token := provider.GenerateToken(appCfg)
+	token, err := provider.GenerateToken(appCfg)
+	require.NoError(t, err)
 
 	// Verify results - should continue even if browser open fails
 	assert.NotNil(t, token)
@@ -251,11 +268,27 @@ func TestAWSProviderGenerateTokenBrowserOpenFailure(t *testing.T) {
 
 // TestNewDefaultAWSProvider tests the NewDefaultAWSProvider function
 func TestNewDefaultAWSProvider(t *testing.T) {
-	provider := NewDefaultAWSProvider()
+	appCfg := appconfig.Default()
+	appCfg.SSO.Region = "eu-west-1"
+	provider, err := NewDefaultAWSProvider(cli.NewMockUi(), appCfg)
+	require.NoError(t, err)
 
 	assert.NotNil(t, provider, "Provider should not be nil")
 	assert.NotNil(t, provider.SSOOIDCClient, "SSOOIDCClient should not be nil")
 	assert.NotNil(t, provider.BrowserOpener, "BrowserOpener should not be nil")
-	assert.NotNil(t, provider.TokenPoller, "TokenPoller should not be nil")
 	assert.NotNil(t, provider.Cfg, "Cfg should not be nil")
+	assert.Equal(t, "eu-west-1", provider.Cfg.Region)
+}
+
+// TestNewAWSProviderRegion tests that the OIDC client uses the SSO region
+func TestNewAWSProviderRegion(t *testing.T) {
+	appCfg := appconfig.Default()
+	appCfg.SSO.Region = "ap-southeast-2"
+
+	provider := newAWSProvider(cli.NewMockUi(), aws.Config{Region: "us-east-1"}, appCfg)
+
+	client, ok := provider.SSOOIDCClient.(*ssooidc.Client)
+	require.True(t, ok)
+	assert.Equal(t, "ap-southeast-2", client.Options().Region)
+	assert.Equal(t, "ap-southeast-2", provider.Cfg.Region)
 }
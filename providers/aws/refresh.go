@@ -0,0 +1,116 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// OAuth grant types used with the SSO OIDC service
+const (
+	grantTypeDeviceCode   = "urn:ietf:params:oauth:grant-type:device_code"
+	grantTypeRefreshToken = "refresh_token"
+)
+
+// clientName is the name the OIDC client is registered with
+const clientName = "aws-sso-config-cli"
+
+// registrationScopes returns the scopes the OIDC client is registered with. The
+// sso:account:access scope makes the service issue refresh tokens.
+func registrationScopes(appCfg *appconfig.Config) []string {
+	var scopes []string
+	for _, scope := range strings.Split(appCfg.SSO.RegistrationScopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		scopes = []string{"sso:account:access"}
+	}
+	return scopes
+}
+
+// registerClientInput returns the input used to register the OIDC client for the
+// configured login flow, allowing its tokens to be refreshed
+func registerClientInput(appCfg *appconfig.Config) *ssooidc.RegisterClientInput {
+	if authCodeFlow(appCfg) {
+		return authCodeRegisterClientInput(appCfg)
+	}
+	return deviceCodeRegisterClientInput(appCfg)
+}
+
+// deviceCodeRegisterClientInput returns the input used to register the OIDC client
+// for the device code login flow
+func deviceCodeRegisterClientInput(appCfg *appconfig.Config) *ssooidc.RegisterClientInput {
+	return &ssooidc.RegisterClientInput{
+		ClientName: aws.String(clientName),
+		ClientType: aws.String("public"),
+		Scopes:     registrationScopes(appCfg),
+		GrantTypes: []string{grantTypeDeviceCode, grantTypeRefreshToken},
+	}
+}
+
+// authCodeRegisterClientInput returns the input used to register the OIDC client
+// for the authorization code login flow with PKCE
+func authCodeRegisterClientInput(appCfg *appconfig.Config) *ssooidc.RegisterClientInput {
+	return &ssooidc.RegisterClientInput{
+		ClientName:   aws.String(clientName),
+		ClientType:   aws.String("public"),
+		Scopes:       registrationScopes(appCfg),
+		GrantTypes:   []string{grantTypeAuthorizationCode, grantTypeRefreshToken},
+		RedirectUris: []string{registeredRedirectURI},
+		IssuerUrl:    aws.String(appCfg.SSOStartURL()),
+	}
+}
+
+// canRefresh reports whether the entry holds a refresh token and a client
+// registration which has not expired
+func (e *SSOCacheEntry) canRefresh(now time.Time) bool {
+	if e.RefreshToken == "" || e.ClientID == "" || e.ClientSecret == "" {
+		return false
+	}
+	return e.RegistrationExpiresAt == nil || now.Before(*e.RegistrationExpiresAt)
+}
+
+// refreshCachedToken exchanges the cached refresh token for a new access token and
+// updates the cache. It returns nil when there is nothing to refresh or the refresh
+// fails, in which case the user has to log in again.
+func refreshCachedToken(ctx context.Context, ui cli.Ui, client SSOOIDCClient, appCfg *appconfig.Config) *string {
+	now := time.Now()
+	entry, err := readCachedToken(appCfg)
+	if err != nil || !entry.matches(appCfg) || !entry.canRefresh(now) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	token, err := client.CreateToken(ctx, &ssooidc.CreateTokenInput{
+		ClientId:     aws.String(entry.ClientID),
+		ClientSecret: aws.String(entry.ClientSecret),
+		GrantType:    aws.String(grantTypeRefreshToken),
+		RefreshToken: aws.String(entry.RefreshToken),
+	})
+	if err != nil || aws.ToString(token.AccessToken) == "" {
+		return nil
+	}
+
+	entry.AccessToken = aws.ToString(token.AccessToken)
+	entry.ExpiresAt = now.Add(time.Duration(token.ExpiresIn) * time.Second).UTC().Truncate(time.Second)
+	// The service may rotate the refresh token
+	if refreshToken := aws.ToString(token.RefreshToken); refreshToken != "" {
+		entry.RefreshToken = refreshToken
+	}
+	if err := writeCachedToken(appCfg, *entry); err != nil {
+		ui.Warn(fmt.Sprintf("Failed to cache token: %v", err))
+	}
+
+	return &entry.AccessToken
+}
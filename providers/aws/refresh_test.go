@@ -0,0 +1,123 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterClientInput(t *testing.T) {
+	appCfg, _ := testCacheConfig(t)
+
+	input := registerClientInput(appCfg)
+	assert.Equal(t, "aws-sso-config-cli", aws.ToString(input.ClientName))
+	assert.Equal(t, []string{"sso:account:access"}, input.Scopes)
+	assert.Equal(t, []string{grantTypeDeviceCode, grantTypeRefreshToken}, input.GrantTypes)
+
+	appCfg.SSO.RegistrationScopes = " sso:account:access , sso-portal:* ,"
+	assert.Equal(t, []string{"sso:account:access", "sso-portal:*"}, registrationScopes(appCfg))
+
+	appCfg.SSO.RegistrationScopes = ""
+	assert.Equal(t, []string{"sso:account:access"}, registrationScopes(appCfg))
+}
+
+func TestCanRefresh(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		entry    SSOCacheEntry
+		expected bool
+	}{
+		{"refreshable", SSOCacheEntry{RefreshToken: "r", ClientID: "id", ClientSecret: "s", RegistrationExpiresAt: &future}, true},
+		{"no registration expiry", SSOCacheEntry{RefreshToken: "r", ClientID: "id", ClientSecret: "s"}, true},
+		{"no refresh token", SSOCacheEntry{ClientID: "id", ClientSecret: "s"}, false},
+		{"no client", SSOCacheEntry{RefreshToken: "r"}, false},
+		{"registration expired", SSOCacheEntry{RefreshToken: "r", ClientID: "id", ClientSecret: "s", RegistrationExpiresAt: &past}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.entry.canRefresh(now))
+		})
+	}
+}
+
+func TestRefreshCachedToken(t *testing.T) {
+	appCfg, _ := testCacheConfig(t)
+	registrationExpiresAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	require.NoError(t, writeCachedToken(appCfg, SSOCacheEntry{
+		AccessToken:           "expired-token",
+		ExpiresAt:             time.Now().Add(-time.Hour),
+		StartURL:              appCfg.SSOStartURL(),
+		Region:                appCfg.SSORegion(),
+		ClientID:              "client-id",
+		ClientSecret:          "client-secret",
+		RegistrationExpiresAt: &registrationExpiresAt,
+		RefreshToken:          "refresh-token",
+	}))
+
+	mockClient := new(MockSSOOIDCClient)
+	mockClient.On("CreateToken", mock.Anything, mock.MatchedBy(func(input *ssooidc.CreateTokenInput) bool {
+		return aws.ToString(input.GrantType) == grantTypeRefreshToken &&
+			aws.ToString(input.RefreshToken) == "refresh-token" &&
+			aws.ToString(input.ClientId) == "client-id" &&
+			aws.ToString(input.ClientSecret) == "client-secret"
+	}), mock.Anything).Return(&ssooidc.CreateTokenOutput{
+		AccessToken:  aws.String("new-token"),
+		RefreshToken: aws.String("rotated-refresh-token"),
+		ExpiresIn:    3600,
+	}, nil).Once()
+
+	token := refreshCachedToken(context.Background(), cli.NewMockUi(), mockClient, appCfg)
+	require.NotNil(t, token)
+	assert.Equal(t, "new-token", *token)
+	mockClient.AssertExpectations(t)
+
+	// The refreshed token is cached and used without another refresh
+	cached, err := readCachedToken(appCfg)
+	require.NoError(t, err)
+	assert.Equal(t, "new-token", cached.AccessToken)
+	assert.Equal(t, "rotated-refresh-token", cached.RefreshToken)
+	assert.Equal(t, "client-id", cached.ClientID)
+	assert.True(t, cached.ExpiresAt.After(time.Now().Add(55*time.Minute)))
+
+	current := getCurrentToken(appCfg)
+	require.NotNil(t, current)
+	assert.Equal(t, "new-token", *current)
+}
+
+func TestRefreshCachedTokenFailure(t *testing.T) {
+	appCfg, _ := testCacheConfig(t)
+
+	// Nothing is cached, so the service is not called
+	mockClient := new(MockSSOOIDCClient)
+	assert.Nil(t, refreshCachedToken(context.Background(), cli.NewMockUi(), mockClient, appCfg))
+	mockClient.AssertNotCalled(t, "CreateToken", mock.Anything, mock.Anything, mock.Anything)
+
+	require.NoError(t, writeCachedToken(appCfg, SSOCacheEntry{
+		AccessToken:  "expired-token",
+		ExpiresAt:    time.Now().Add(-time.Hour),
+		StartURL:     appCfg.SSOStartURL(),
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RefreshToken: "revoked-refresh-token",
+	}))
+
+	// A rejected refresh falls back to logging in
+	mockClient.On("CreateToken", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("invalid_grant"))
+	assert.Nil(t, refreshCachedToken(context.Background(), cli.NewMockUi(), mockClient, appCfg))
+
+	cached, err := readCachedToken(appCfg)
+	require.NoError(t, err)
+	assert.Equal(t, "expired-token", cached.AccessToken)
+}
@@ -0,0 +1,118 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec // Cache file names only
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// registrationExpirySkew is how long before it expires a cached client
+// registration is replaced, so that it does not expire during a login
+const registrationExpirySkew = 15 * time.Minute
+
+// clientRegistration is an OIDC client registration cached next to the tokens,
+// in the schema the AWS CLI uses for its registrations
+type clientRegistration struct {
+	ClientID     string    `json:"clientId"`
+	ClientSecret string    `json:"clientSecret"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	Scopes       []string  `json:"scopes,omitempty"`
+	GrantTypes   []string  `json:"grantTypes,omitempty"`
+}
+
+// registrationCachePath returns the cache file for registrations made with the
+// input. Registrations are keyed by the start URL, region, scopes and grant types
+// so that a change to any of them registers a new client.
+func registrationCachePath(appCfg *appconfig.Config, input *ssooidc.RegisterClientInput) (string, error) {
+	dir, err := ssoCacheDir()
+	if err != nil {
+		return "", err
+	}
+	key := strings.Join([]string{
+		clientName,
+		normalizeStartURL(appCfg.SSOStartURL()),
+		appCfg.SSORegion(),
+		strings.Join(input.Scopes, ","),
+		strings.Join(input.GrantTypes, ","),
+	}, "|")
+	sum := sha1.Sum([]byte(key)) //nolint:gosec // Not used for security
+	return filepath.Join(dir, "aws-sso-config-client-"+hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// readRegistration returns the cached registration for the input when it is still valid
+func readRegistration(appCfg *appconfig.Config, input *ssooidc.RegisterClientInput, now time.Time) *ssooidc.RegisterClientOutput {
+	path, err := registrationCachePath(appCfg, input)
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var registration clientRegistration
+	if err := json.Unmarshal(data, &registration); err != nil {
+		return nil
+	}
+	if registration.ClientID == "" || registration.ClientSecret == "" || !now.Add(registrationExpirySkew).Before(registration.ExpiresAt) {
+		return nil
+	}
+
+	return &ssooidc.RegisterClientOutput{
+		ClientId:              aws.String(registration.ClientID),
+		ClientSecret:          aws.String(registration.ClientSecret),
+		ClientSecretExpiresAt: registration.ExpiresAt.Unix(),
+	}
+}
+
+// writeRegistration caches a registration made with the input. Registrations
+// without an expiry are not cached.
+func writeRegistration(appCfg *appconfig.Config, input *ssooidc.RegisterClientInput, register *ssooidc.RegisterClientOutput) error {
+	if register.ClientSecretExpiresAt <= 0 {
+		return nil
+	}
+	path, err := registrationCachePath(appCfg, input)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(clientRegistration{
+		ClientID:     aws.ToString(register.ClientId),
+		ClientSecret: aws.ToString(register.ClientSecret),
+		ExpiresAt:    time.Unix(register.ClientSecretExpiresAt, 0).UTC(),
+		Scopes:       input.Scopes,
+		GrantTypes:   input.GrantTypes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode client registration: %w", err)
+	}
+	return writeCacheFile(path, data)
+}
+
+// registerClient returns the cached client registration for the configuration, or
+// registers a new client and caches it until it expires
+func registerClient(ctx context.Context, ui cli.Ui, client SSOOIDCClient, appCfg *appconfig.Config) (*ssooidc.RegisterClientOutput, error) {
+	input := registerClientInput(appCfg)
+	if register := readRegistration(appCfg, input, time.Now()); register != nil {
+		return register, nil
+	}
+
+	register, err := client.RegisterClient(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeRegistration(appCfg, input, register); err != nil {
+		ui.Warn(fmt.Sprintf("Failed to cache client registration: %v", err))
+	}
+	return register, nil
+}
@@ -0,0 +1,114 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrationCachePath(t *testing.T) {
+	appCfg, _ := testCacheConfig(t)
+	input := registerClientInput(appCfg)
+
+	path, err := registrationCachePath(appCfg, input)
+	require.NoError(t, err)
+
+	// Equivalent start URLs share a registration
+	appCfg.SSO.StartURL += "/"
+	same, err := registrationCachePath(appCfg, input)
+	require.NoError(t, err)
+	assert.Equal(t, path, same)
+
+	// Registrations for other scopes are kept apart
+	appCfg.SSO.RegistrationScopes = "sso:account:access,sso-portal:*"
+	other, err := registrationCachePath(appCfg, registerClientInput(appCfg))
+	require.NoError(t, err)
+	assert.NotEqual(t, path, other)
+
+	// And never collide with a token cache file
+	tokenPath, err := cacheFilePath(appCfg)
+	require.NoError(t, err)
+	assert.NotEqual(t, tokenPath, path)
+}
+
+func TestWriteAndReadRegistration(t *testing.T) {
+	appCfg, _ := testCacheConfig(t)
+	input := registerClientInput(appCfg)
+	now := time.Now()
+
+	assert.Nil(t, readRegistration(appCfg, input, now))
+
+	// Registrations without an expiry are not cached
+	require.NoError(t, writeRegistration(appCfg, input, &ssooidc.RegisterClientOutput{
+		ClientId:     aws.String("client-id"),
+		ClientSecret: aws.String("client-secret"),
+	}))
+	assert.Nil(t, readRegistration(appCfg, input, now))
+
+	expiresAt := now.Add(90 * 24 * time.Hour).Unix()
+	require.NoError(t, writeRegistration(appCfg, input, &ssooidc.RegisterClientOutput{
+		ClientId:              aws.String("client-id"),
+		ClientSecret:          aws.String("client-secret"),
+		ClientSecretExpiresAt: expiresAt,
+	}))
+
+	path, err := registrationCachePath(appCfg, input)
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var cached clientRegistration
+	require.NoError(t, json.Unmarshal(data, &cached))
+	assert.Equal(t, input.Scopes, cached.Scopes)
+	assert.Equal(t, input.GrantTypes, cached.GrantTypes)
+
+	register := readRegistration(appCfg, input, now)
+	require.NotNil(t, register)
+	assert.Equal(t, "client-id", aws.ToString(register.ClientId))
+	assert.Equal(t, "client-secret", aws.ToString(register.ClientSecret))
+	assert.Equal(t, expiresAt, register.ClientSecretExpiresAt)
+
+	// Registrations about to expire are replaced
+	assert.Nil(t, readRegistration(appCfg, input, time.Unix(expiresAt, 0).Add(-time.Minute)))
+}
+
+func TestRegisterClientReusesRegistration(t *testing.T) {
+	appCfg, _ := testCacheConfig(t)
+
+	mockClient := new(MockSSOOIDCClient)
+	mockClient.On("RegisterClient", mock.Anything, mock.Anything, mock.Anything).Return(&ssooidc.RegisterClientOutput{
+		ClientId:              aws.String("client-id"),
+		ClientSecret:          aws.String("client-secret"),
+		ClientSecretExpiresAt: time.Now().Add(90 * 24 * time.Hour).Unix(),
+	}, nil).Once()
+
+	first, err := registerClient(context.Background(), cli.NewMockUi(), mockClient, appCfg)
+	require.NoError(t, err)
+	second, err := registerClient(context.Background(), cli.NewMockUi(), mockClient, appCfg)
+	require.NoError(t, err)
+
+	// The second call uses the cached registration
+	assert.Equal(t, aws.ToString(first.ClientId), aws.ToString(second.ClientId))
+	assert.Equal(t, first.ClientSecretExpiresAt, second.ClientSecretExpiresAt)
+	mockClient.AssertNumberOfCalls(t, "RegisterClient", 1)
+
+	// A change of scopes registers a new client
+	appCfg.SSO.RegistrationScopes = "sso:account:access,sso-portal:*"
+	mockClient.On("RegisterClient", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("throttled")).Once()
+	_, err = registerClient(context.Background(), cli.NewMockUi(), mockClient, appCfg)
+	require.Error(t, err)
+	mockClient.AssertNumberOfCalls(t, "RegisterClient", 2)
+}
@@ -0,0 +1,235 @@
+package aws
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // Cache file names only
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+)
+
+// roleCredentialsExpirySkew is how long before they expire cached role
+// credentials are replaced, so that they do not expire while in use
+const roleCredentialsExpirySkew = 5 * time.Minute
+
+// Role credential cache files, in the SSO cache directory. Each file holds the
+// credentials of one role, encrypted with the key in roleCredentialsKeyFile.
+const (
+	roleCredentialsFilePrefix = "aws-sso-config-credentials-"
+	roleCredentialsFileSuffix = ".enc"
+	roleCredentialsKeyFile    = "aws-sso-config-credentials.key"
+	roleCredentialsKeySize    = 32
+)
+
+// roleCredentialsCache is the locked cache file for the credentials of a role
+type roleCredentialsCache struct {
+	path   string
+	aead   cipher.AEAD
+	aad    []byte
+	unlock func()
+}
+
+// portalCacheID returns the part of the role credential cache file names which
+// identifies the SSO portal, so that logging out can remove its credentials
+func portalCacheID(startURL string) string {
+	sum := sha1.Sum([]byte(normalizeStartURL(startURL))) //nolint:gosec // Not used for security
+	return hex.EncodeToString(sum[:])
+}
+
+// roleCredentialsCachePath returns the cache file for the credentials of the
+// profile's role. Credentials are keyed by the start URL, account and role.
+func roleCredentialsCachePath(profile *SSOProfile) (string, error) {
+	dir, err := ssoCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(profile.AccountID + "|" + profile.RoleName)) //nolint:gosec // Not used for security
+	name := roleCredentialsFilePrefix + portalCacheID(profile.StartURL) + "-" + hex.EncodeToString(sum[:]) + roleCredentialsFileSuffix
+	return filepath.Join(dir, name), nil
+}
+
+// roleCredentialsKey returns the key the role credential cache is encrypted with,
+// creating it readable only by the user on first use. A key which other users can
+// read is not used.
+func roleCredentialsKey(dir string) ([]byte, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	path := filepath.Join(dir, roleCredentialsKeyFile)
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	case runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0:
+		return nil, fmt.Errorf("%s is accessible by other users", path)
+	default:
+		key, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		// A damaged key is replaced, which invalidates the cached credentials
+		if len(key) == roleCredentialsKeySize {
+			return key, nil
+		}
+	}
+
+	key := make([]byte, roleCredentialsKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate cache key: %w", err)
+	}
+	if err := writeCacheFile(path, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// openRoleCredentialsCache locks the cache file for the profile's role. The lock
+// is held until unlock is called, so that parallel processes wait for one of
+// them to get the credentials instead of all calling the service.
+func openRoleCredentialsCache(profile *SSOProfile) (*roleCredentialsCache, error) {
+	path, err := roleCredentialsCachePath(profile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := roleCredentialsKey(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache cipher: %w", err)
+	}
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return nil, err
+	}
+
+	return &roleCredentialsCache{
+		path: path,
+		aead: aead,
+		// Binding the key to the file stops one role's file standing in for another's
+		aad:    []byte(strings.Join([]string{normalizeStartURL(profile.StartURL), profile.AccountID, profile.RoleName}, "|")),
+		unlock: unlock,
+	}, nil
+}
+
+// read returns the cached credentials when they are still valid for at least
+// roleCredentialsExpirySkew after now
+func (c *roleCredentialsCache) read(now time.Time) *RoleCredentials {
+	data, err := os.ReadFile(c.path)
+	if err != nil || len(data) < c.aead.NonceSize() {
+		return nil
+	}
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, c.aad)
+	if err != nil {
+		return nil
+	}
+	var creds RoleCredentials
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return nil
+	}
+	if creds.AccessKeyID == "" || !now.Add(roleCredentialsExpirySkew).Before(creds.Expiration) {
+		return nil
+	}
+	return &creds
+}
+
+// write encrypts the credentials into the cache file
+func (c *roleCredentialsCache) write(creds *RoleCredentials) error {
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return fmt.Errorf("failed to encode role credentials: %w", err)
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to encrypt role credentials: %w", err)
+	}
+	return writeCacheFile(c.path, c.aead.Seal(nonce, nonce, plaintext, c.aad))
+}
+
+// lookupRoleCredentials returns the cached credentials for the profile's role, or
+// nil when there are none which are still valid
+func lookupRoleCredentials(profile *SSOProfile) *RoleCredentials {
+	cache, err := openRoleCredentialsCache(profile)
+	if err != nil {
+		return nil
+	}
+	defer cache.unlock()
+	return cache.read(time.Now())
+}
+
+// cachedRoleCredentials returns the cached credentials for the profile's role, or
+// the credentials returned by fetch, which are cached until shortly before they
+// expire. The credentials are still returned when the cache cannot be used. The
+// cache is locked while fetch runs, so fetch must not wait on the user.
+func cachedRoleCredentials(ui cli.Ui, profile *SSOProfile, fetch func() (*RoleCredentials, error)) (*RoleCredentials, error) {
+	cache, err := openRoleCredentialsCache(profile)
+	if err != nil {
+		ui.Warn(fmt.Sprintf("Failed to open role credentials cache: %v", err))
+		return fetch()
+	}
+	defer cache.unlock()
+
+	if creds := cache.read(time.Now()); creds != nil {
+		return creds, nil
+	}
+	creds, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.write(creds); err != nil {
+		ui.Warn(fmt.Sprintf("Failed to cache role credentials: %v", err))
+	}
+	return creds, nil
+}
+
+// removeRoleCredentials removes the cached role credentials for the SSO portal,
+// reporting whether any were removed. Each file is removed under its lock, which
+// is kept so that processes waiting on it still exclude each other.
+func removeRoleCredentials(startURL string) (bool, error) {
+	dir, err := ssoCacheDir()
+	if err != nil {
+		return false, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, roleCredentialsFilePrefix+portalCacheID(startURL)+"-*"+roleCredentialsFileSuffix))
+	if err != nil {
+		return false, fmt.Errorf("failed to list cached role credentials: %w", err)
+	}
+
+	removed := false
+	for _, path := range paths {
+		unlock, err := lockFile(path + ".lock")
+		if err != nil {
+			return removed, err
+		}
+		err = os.Remove(path)
+		unlock()
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		removed = true
+	}
+	return removed, nil
+}
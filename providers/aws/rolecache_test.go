@@ -0,0 +1,236 @@
+package aws
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRoleProfile returns a profile for the role credential cache tests, caching
+// to a temporary directory
+func testRoleProfile(t *testing.T) (*SSOProfile, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("AWS_SSO_CACHE_PATH", dir)
+	return &SSOProfile{AccountID: "123456789012", RoleName: "Admin", StartURL: "https://test.awsapps.com/start", SSORegion: "us-west-2"}, dir
+}
+
+// countingFetch returns a fetch function returning credentials which expire after
+// the duration, and the number of times it was called
+func countingFetch(expiresIn time.Duration) (func() (*RoleCredentials, error), *int32) {
+	var calls int32
+	return func() (*RoleCredentials, error) {
+		atomic.AddInt32(&calls, 1)
+		return &RoleCredentials{
+			AccessKeyID:     "ASIA",
+			SecretAccessKey: "secret",
+			SessionToken:    "session",
+			Expiration:      time.Now().Add(expiresIn).UTC().Truncate(time.Second),
+		}, nil
+	}, &calls
+}
+
+func TestCachedRoleCredentials(t *testing.T) {
+	profile, dir := testRoleProfile(t)
+	fetch, calls := countingFetch(time.Hour)
+
+	creds, err := cachedRoleCredentials(cli.NewMockUi(), profile, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, "ASIA", creds.AccessKeyID)
+
+	cached, err := cachedRoleCredentials(cli.NewMockUi(), profile, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, creds, cached)
+	assert.Equal(t, int32(1), *calls)
+
+	// The credentials are not stored in plain text
+	path, err := roleCredentialsCachePath(profile)
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+
+	// The key is readable only by the user
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Join(dir, roleCredentialsKeyFile))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	// Another role has its own entry
+	other := *profile
+	other.RoleName = "ReadOnly"
+	_, err = cachedRoleCredentials(cli.NewMockUi(), &other, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), *calls)
+}
+
+func TestCachedRoleCredentialsExpiry(t *testing.T) {
+	profile, _ := testRoleProfile(t)
+
+	// Credentials expiring within the skew are fetched again
+	fetch, calls := countingFetch(roleCredentialsExpirySkew - time.Minute)
+	for range 2 {
+		_, err := cachedRoleCredentials(cli.NewMockUi(), profile, fetch)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), *calls)
+}
+
+func TestCachedRoleCredentialsFetchError(t *testing.T) {
+	profile, _ := testRoleProfile(t)
+
+	_, err := cachedRoleCredentials(cli.NewMockUi(), profile, func() (*RoleCredentials, error) {
+		return nil, ErrLoginCanceled
+	})
+	assert.ErrorIs(t, err, ErrLoginCanceled)
+
+	path, err := roleCredentialsCachePath(profile)
+	require.NoError(t, err)
+	assert.NoFileExists(t, path)
+}
+
+func TestCachedRoleCredentialsConcurrent(t *testing.T) {
+	profile, _ := testRoleProfile(t)
+	fetch, calls := countingFetch(time.Hour)
+
+	// Only the first of the parallel callers gets credentials, the others wait for it
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			creds, err := cachedRoleCredentials(cli.NewMockUi(), profile, fetch)
+			assert.NoError(t, err)
+			assert.Equal(t, "ASIA", creds.AccessKeyID)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), *calls)
+}
+
+func TestRoleCredentialsCacheTampering(t *testing.T) {
+	profile, _ := testRoleProfile(t)
+	fetch, calls := countingFetch(time.Hour)
+
+	_, err := cachedRoleCredentials(cli.NewMockUi(), profile, fetch)
+	require.NoError(t, err)
+
+	// A file copied over another role's entry is not accepted
+	other := *profile
+	other.AccountID = "210987654321"
+	path, err := roleCredentialsCachePath(profile)
+	require.NoError(t, err)
+	otherPath, err := roleCredentialsCachePath(&other)
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(otherPath, data, 0600))
+
+	_, err = cachedRoleCredentials(cli.NewMockUi(), &other, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), *calls)
+
+	// Nor is a damaged file
+	data[len(data)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	_, err = cachedRoleCredentials(cli.NewMockUi(), profile, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), *calls)
+}
+
+func TestRoleCredentialsKey(t *testing.T) {
+	dir := t.TempDir()
+
+	key, err := roleCredentialsKey(dir)
+	require.NoError(t, err)
+	assert.Len(t, key, roleCredentialsKeySize)
+
+	again, err := roleCredentialsKey(dir)
+	require.NoError(t, err)
+	assert.Equal(t, key, again)
+
+	// A damaged key is replaced
+	path := filepath.Join(dir, roleCredentialsKeyFile)
+	require.NoError(t, os.WriteFile(path, []byte("short"), 0600))
+	replaced, err := roleCredentialsKey(dir)
+	require.NoError(t, err)
+	assert.Len(t, replaced, roleCredentialsKeySize)
+	assert.NotEqual(t, key, replaced)
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// A key other users can read is not used
+	require.NoError(t, os.Chmod(path, 0644))
+	_, err = roleCredentialsKey(dir)
+	assert.ErrorContains(t, err, "is accessible by other users")
+}
+
+func TestCachedRoleCredentialsWithoutCache(t *testing.T) {
+	profile, dir := testRoleProfile(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not checked on Windows")
+	}
+	keyPath := filepath.Join(dir, roleCredentialsKeyFile)
+	require.NoError(t, os.WriteFile(keyPath, make([]byte, roleCredentialsKeySize), 0644))
+
+	// The credentials are returned, with a warning, when the cache cannot be used
+	ui := cli.NewMockUi()
+	fetch, calls := countingFetch(time.Hour)
+	creds, err := cachedRoleCredentials(ui, profile, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, "ASIA", creds.AccessKeyID)
+	assert.Contains(t, ui.ErrorWriter.String(), "Failed to open role credentials cache")
+
+	_, err = cachedRoleCredentials(ui, profile, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), *calls)
+}
+
+func TestRemoveRoleCredentials(t *testing.T) {
+	profile, _ := testRoleProfile(t)
+	fetch, _ := countingFetch(time.Hour)
+
+	other := *profile
+	other.StartURL = "https://other.awsapps.com/start"
+	for _, p := range []*SSOProfile{profile, &other} {
+		_, err := cachedRoleCredentials(cli.NewMockUi(), p, fetch)
+		require.NoError(t, err)
+	}
+
+	removed, err := removeRoleCredentials(profile.StartURL + "/")
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	path, err := roleCredentialsCachePath(profile)
+	require.NoError(t, err)
+	assert.NoFileExists(t, path)
+	// The lock is kept for processes which may be waiting on it
+	assert.FileExists(t, path+".lock")
+	// Other portals' credentials are kept
+	otherPath, err := roleCredentialsCachePath(&other)
+	require.NoError(t, err)
+	assert.FileExists(t, otherPath)
+	assert.FileExists(t, otherPath+".lock")
+
+	removed, err = removeRoleCredentials(profile.StartURL)
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestRemoveRoleCredentialsMissingCache(t *testing.T) {
+	t.Setenv("AWS_SSO_CACHE_PATH", filepath.Join(t.TempDir(), "missing"))
+
+	removed, err := removeRoleCredentials("https://test.awsapps.com/start")
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
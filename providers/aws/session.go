@@ -0,0 +1,61 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/bigkevmcd/go-configparser"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+// ssoSession is an [sso-session] section of the AWS config file
+type ssoSession struct {
+	StartURL           string
+	Region             string
+	RegistrationScopes string
+}
+
+// readSSOSession reads the named [sso-session] section of the AWS config file
+func readSSOSession(awsConfig *configparser.ConfigParser, configFile, name string) (*ssoSession, error) {
+	section := "sso-session " + name
+	if !awsConfig.HasSection(section) {
+		return nil, fmt.Errorf("sso-session %q not found in %s", name, configFile)
+	}
+	startURL, err := awsConfig.Get(section, "sso_start_url")
+	if err != nil || startURL == "" {
+		return nil, fmt.Errorf("sso-session %q has no sso_start_url", name)
+	}
+	region, err := awsConfig.Get(section, "sso_region")
+	if err != nil || region == "" {
+		return nil, fmt.Errorf("sso-session %q has no sso_region", name)
+	}
+	// Scopes are optional
+	scopes, _ := awsConfig.Get(section, "sso_registration_scopes")
+
+	return &ssoSession{StartURL: startURL, Region: region, RegistrationScopes: scopes}, nil
+}
+
+// UseSSOSession points the configuration at the named [sso-session] section of
+// the AWS config file, so that tokens are cached under the session name as the
+// AWS CLI does for sso-session profiles
+func UseSSOSession(appCfg *appconfig.Config, name string) error {
+	configFile := appCfg.ConfigFile()
+	awsConfig, err := configparser.NewConfigParserFromFile(configFile)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", configFile, err)
+	}
+
+	session, err := readSSOSession(awsConfig, configFile, name)
+	if err != nil {
+		return err
+	}
+
+	appCfg.SSO.StartURL = session.StartURL
+	appCfg.SSO.Region = session.Region
+	appCfg.SSO.SessionName = name
+	if session.RegistrationScopes != "" {
+		appCfg.SSO.RegistrationScopes = session.RegistrationScopes
+	}
+	appCfg.Generate.ProfileFormat = appconfig.ProfileFormatSSOSession
+	return nil
+}
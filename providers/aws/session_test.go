@@ -0,0 +1,41 @@
+package aws
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appconfig "github.com/blairham/aws-sso-config/providers/config"
+)
+
+func TestUseSSOSession(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(configFile, []byte(`[sso-session my-sso]
+sso_start_url = https://other.awsapps.com/start
+sso_region = eu-west-1
+sso_registration_scopes = sso:account:access
+
+[sso-session incomplete]
+sso_start_url = https://other.awsapps.com/start
+`), 0600))
+
+	appCfg := appconfig.Default()
+	appCfg.AWS.ConfigFile = configFile
+
+	require.NoError(t, UseSSOSession(appCfg, "my-sso"))
+	assert.Equal(t, "https://other.awsapps.com/start", appCfg.SSOStartURL())
+	assert.Equal(t, "eu-west-1", appCfg.SSORegion())
+	assert.Equal(t, "my-sso", appCfg.SSO.SessionName)
+	assert.Equal(t, "sso:account:access", appCfg.SSO.RegistrationScopes)
+	// Tokens are cached under the session name
+	assert.Equal(t, "my-sso", cacheKey(appCfg))
+
+	assert.ErrorContains(t, UseSSOSession(appCfg, "missing"), `sso-session "missing" not found`)
+	assert.ErrorContains(t, UseSSOSession(appCfg, "incomplete"), "has no sso_region")
+
+	appCfg.AWS.ConfigFile = filepath.Join(t.TempDir(), "missing")
+	assert.ErrorContains(t, UseSSOSession(appCfg, "my-sso"), "error reading")
+}
@@ -1,10 +1,16 @@
 package config
 
+import "fmt"
+
 // Config holds the application configuration
 type Config struct {
 	// Provider configurations
 	SSO SSOConfig `mapstructure:"sso" toml:"sso"`
 	AWS AWSConfig `mapstructure:"aws" toml:"aws"`
+
+	// Command configurations
+	Generate GenerateConfig `mapstructure:"generate" toml:"generate"`
+	Login    LoginConfig    `mapstructure:"login" toml:"login"`
 }
 
 // Backward compatibility getters
@@ -20,6 +26,11 @@ func (c *Config) SSORole() string {
 	return c.SSO.Role
 }
 
+// UsesSSOSession reports whether profiles reference an [sso-session] section
+func (c *Config) UsesSSOSession() bool {
+	return c.Generate.ProfileFormat == ProfileFormatSSOSession
+}
+
 // AWS configuration getters
 func (c *Config) DefaultRegion() string {
 	return c.AWS.DefaultRegion
@@ -37,14 +48,25 @@ func (c *Config) Validate() error {
 	if err := c.AWS.Validate(); err != nil {
 		return err
 	}
+	if err := c.Generate.Validate(); err != nil {
+		return err
+	}
+	if err := c.Login.Validate(); err != nil {
+		return err
+	}
+	if c.UsesSSOSession() && c.SSO.SessionName == "" {
+		return fmt.Errorf("SSO session name is required for the %s profile format", ProfileFormatSSOSession)
+	}
 	return nil
 }
 
 // Default returns a default configuration
 func Default() *Config {
 	return &Config{
-		SSO: DefaultSSO(),
-		AWS: DefaultAWS(),
+		SSO:      DefaultSSO(),
+		AWS:      DefaultAWS(),
+		Generate: DefaultGenerate(),
+		Login:    DefaultLogin(),
 	}
 }
 
@@ -52,4 +74,6 @@ func Default() *Config {
 func (c *Config) SetDefaults() {
 	c.SSO.SetDefaults()
 	c.AWS.SetDefaults()
+	c.Generate.SetDefaults()
+	c.Login.SetDefaults()
 }
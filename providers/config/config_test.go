@@ -153,6 +153,54 @@ func TestConfigManagerSaveProviderConfig(t *testing.T) {
 		assert.Equal(t, "/custom/aws/config", config.AWS.ConfigFile)
 	})
 
+	t.Run("save generate config", func(t *testing.T) {
+		tempDir := t.TempDir()
+		configFile := filepath.Join(tempDir, "test-config")
+		cm := NewConfigManager(configFile)
+
+		generateConfig := GenerateConfig{
+			AllRoles:        true,
+			ProfileTemplate: "{{ .AccountName | lower }}-{{ .RoleName }}",
+			Include:         []string{"prod-*"},
+			Exclude:         []string{"id:123456789012", "role:Admin"},
+			Prune:           true,
+			DryRun:          true,
+			Confirm:         true,
+			MergePolicy:     MergePolicyOnlyManagedKeys,
+
+			CredentialProcess: true,
+		}
+
+		err := cm.SaveProviderConfig("generate", generateConfig)
+		require.NoError(t, err)
+
+		config, err := cm.Load()
+		require.NoError(t, err)
+		assert.True(t, config.Generate.AllRoles)
+		assert.Equal(t, "{{ .AccountName | lower }}-{{ .RoleName }}", config.Generate.ProfileTemplate)
+		assert.Equal(t, []string{"prod-*"}, config.Generate.Include)
+		assert.Equal(t, []string{"id:123456789012", "role:Admin"}, config.Generate.Exclude)
+		assert.True(t, config.Generate.Prune)
+		assert.True(t, config.Generate.DryRun)
+		assert.True(t, config.Generate.Confirm)
+		assert.Equal(t, MergePolicyOnlyManagedKeys, config.Generate.MergePolicy)
+		assert.True(t, config.Generate.CredentialProcess)
+	})
+
+	t.Run("save login config", func(t *testing.T) {
+		tempDir := t.TempDir()
+		configFile := filepath.Join(tempDir, "test-config")
+		cm := NewConfigManager(configFile)
+
+		err := cm.SaveProviderConfig("login", LoginConfig{Headless: true, Flow: LoginFlowPKCE})
+		require.NoError(t, err)
+
+		config, err := cm.Load()
+		require.NoError(t, err)
+		assert.True(t, config.Login.Headless)
+		assert.Equal(t, LoginFlowPKCE, config.Login.Flow)
+	})
+
 	t.Run("save to existing config preserves other sections", func(t *testing.T) {
 		tempDir := t.TempDir()
 		configFile := filepath.Join(tempDir, "test-config")
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Fields a filter rule can match against
+const (
+	FilterFieldAccount = "account"
+	FilterFieldID      = "id"
+	FilterFieldRole    = "role"
+)
+
+// FilterRule matches an account name, account ID or role name against a glob or
+// regular expression. Rules are written as [field:]pattern, where field is one of
+// account (the default), id or role, and a pattern wrapped in slashes is treated
+// as a regular expression, e.g. "sandbox-*", "id:123456789012" or "role:/^ReadOnly/".
+type FilterRule struct {
+	Field   string
+	Pattern string
+
+	regex *regexp.Regexp
+}
+
+// ParseFilterRule parses a filter rule
+func ParseFilterRule(rule string) (FilterRule, error) {
+	field, pattern := FilterFieldAccount, strings.TrimSpace(rule)
+	if prefix, rest, found := strings.Cut(pattern, ":"); found {
+		switch prefix {
+		case FilterFieldAccount, FilterFieldID, FilterFieldRole:
+			field, pattern = prefix, rest
+		}
+	}
+
+	if pattern == "" {
+		return FilterRule{}, fmt.Errorf("invalid filter rule %q: pattern is empty", rule)
+	}
+
+	parsed := FilterRule{Field: field, Pattern: pattern}
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		regex, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return FilterRule{}, fmt.Errorf("invalid filter rule %q: %w", rule, err)
+		}
+		parsed.regex = regex
+	} else if _, err := path.Match(pattern, ""); err != nil {
+		return FilterRule{}, fmt.Errorf("invalid filter rule %q: %w", rule, err)
+	}
+
+	return parsed, nil
+}
+
+// ParseFilterRules parses a list of filter rules
+func ParseFilterRules(rules []string) ([]FilterRule, error) {
+	parsed := make([]FilterRule, 0, len(rules))
+	for _, rule := range rules {
+		filterRule, err := ParseFilterRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, filterRule)
+	}
+	return parsed, nil
+}
+
+// Matches reports whether the value matches the rule's pattern
+func (r FilterRule) Matches(value string) bool {
+	if r.regex != nil {
+		return r.regex.MatchString(value)
+	}
+	matched, _ := path.Match(r.Pattern, value)
+	return matched
+}
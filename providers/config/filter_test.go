@@ -0,0 +1,65 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilterRule(t *testing.T) {
+	tests := []struct {
+		rule    string
+		field   string
+		pattern string
+	}{
+		{"sandbox-*", FilterFieldAccount, "sandbox-*"},
+		{"account:prod-*", FilterFieldAccount, "prod-*"},
+		{"id:123456789012", FilterFieldID, "123456789012"},
+		{"role:/^ReadOnly/", FilterFieldRole, "/^ReadOnly/"},
+		{"team:platform", FilterFieldAccount, "team:platform"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := ParseFilterRule(tt.rule)
+			require.NoError(t, err)
+			assert.Equal(t, tt.field, rule.Field)
+			assert.Equal(t, tt.pattern, rule.Pattern)
+		})
+	}
+
+	for _, invalid := range []string{"", "role:", "id:[", "account:/(/"} {
+		_, err := ParseFilterRule(invalid)
+		assert.Error(t, err, "rule %q should be invalid", invalid)
+	}
+}
+
+func TestFilterRuleMatches(t *testing.T) {
+	glob, err := ParseFilterRule("sandbox-*")
+	require.NoError(t, err)
+	assert.True(t, glob.Matches("sandbox-alice"))
+	assert.False(t, glob.Matches("prod"))
+
+	regex, err := ParseFilterRule("role:/^(Admin|ReadOnly)$/")
+	require.NoError(t, err)
+	assert.True(t, regex.Matches("Admin"))
+	assert.True(t, regex.Matches("ReadOnly"))
+	assert.False(t, regex.Matches("AdminAccess"))
+}
+
+func TestGenerateConfigValidateFilters(t *testing.T) {
+	generate := GenerateConfig{Include: []string{"prod-*"}, Exclude: []string{"id:123456789012"}}
+	assert.NoError(t, generate.Validate())
+
+	generate.Include = []string{"role:/(/"}
+	err := generate.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid generate include")
+
+	generate.Include = nil
+	generate.Exclude = []string{"["}
+	err = generate.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid generate exclude")
+}
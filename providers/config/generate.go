@@ -0,0 +1,135 @@
+package config
+
+import "fmt"
+
+// Formats for generated profiles
+const (
+	// ProfileFormatLegacy repeats sso_start_url and sso_region in every profile
+	ProfileFormatLegacy = "legacy"
+	// ProfileFormatSSOSession writes a single [sso-session] section referenced by every profile
+	ProfileFormatSSOSession = "sso-session"
+)
+
+// Policies for merging generated values into existing sections
+const (
+	// MergePolicyOverwrite sets every generated key, replacing local edits
+	MergePolicyOverwrite = "overwrite"
+	// MergePolicyPreserveExisting only adds keys which are not already set
+	MergePolicyPreserveExisting = "preserve-existing"
+	// MergePolicyOnlyManagedKeys updates the SSO identity keys and only adds other keys, such as region, when missing
+	MergePolicyOnlyManagedKeys = "only-managed-keys"
+)
+
+// GenerateConfig holds configuration for the generate command
+type GenerateConfig struct {
+	AllRoles          bool     `mapstructure:"all_roles" toml:"all_roles"`
+	ProfileTemplate   string   `mapstructure:"profile_template" toml:"profile_template"`
+	Include           []string `mapstructure:"include" toml:"include"`
+	Exclude           []string `mapstructure:"exclude" toml:"exclude"`
+	ProfileFormat     string   `mapstructure:"profile_format" toml:"profile_format"`
+	Prune             bool     `mapstructure:"prune" toml:"prune"`
+	DryRun            bool     `mapstructure:"dry_run" toml:"dry_run"`
+	Confirm           bool     `mapstructure:"confirm" toml:"confirm"`
+	MergePolicy       string   `mapstructure:"merge_policy" toml:"merge_policy"`
+	CredentialProcess bool     `mapstructure:"credential_process" toml:"credential_process"`
+}
+
+// DefaultGenerate returns the default generate configuration
+func DefaultGenerate() GenerateConfig {
+	return GenerateConfig{
+		AllRoles:          false,
+		ProfileTemplate:   "",
+		Include:           []string{},
+		Exclude:           []string{},
+		ProfileFormat:     ProfileFormatLegacy,
+		Prune:             false,
+		DryRun:            false,
+		Confirm:           false,
+		MergePolicy:       MergePolicyOverwrite,
+		CredentialProcess: false,
+	}
+}
+
+// Validate validates the generate configuration
+func (g *GenerateConfig) Validate() error {
+	switch g.ProfileFormat {
+	case "", ProfileFormatLegacy, ProfileFormatSSOSession:
+	default:
+		return fmt.Errorf("generate profile format must be %q or %q", ProfileFormatLegacy, ProfileFormatSSOSession)
+	}
+	switch g.MergePolicy {
+	case "", MergePolicyOverwrite, MergePolicyPreserveExisting, MergePolicyOnlyManagedKeys:
+	default:
+		return fmt.Errorf("generate merge policy must be %q, %q or %q", MergePolicyOverwrite, MergePolicyPreserveExisting, MergePolicyOnlyManagedKeys)
+	}
+	if _, err := ParseFilterRules(g.Include); err != nil {
+		return fmt.Errorf("invalid generate include: %w", err)
+	}
+	if _, err := ParseFilterRules(g.Exclude); err != nil {
+		return fmt.Errorf("invalid generate exclude: %w", err)
+	}
+	return nil
+}
+
+// SetDefaults sets default values for any missing generate configuration
+func (g *GenerateConfig) SetDefaults() {
+	if g.ProfileFormat == "" {
+		g.ProfileFormat = ProfileFormatLegacy
+	}
+	if g.MergePolicy == "" {
+		g.MergePolicy = MergePolicyOverwrite
+	}
+}
+
+// GetSectionName returns the TOML section name for generate configuration
+func (g *GenerateConfig) GetSectionName() string {
+	return "generate"
+}
+
+// GetDefaultContent returns the default TOML content for generate section
+func (g *GenerateConfig) GetDefaultContent() string {
+	return `# Generate Configuration
+[generate]
+all_roles = false
+
+# Go template used to name generated profiles. Available fields are
+# .AccountID, .AccountName, .Email, .RoleName and .Region, and the
+# functions lower, upper, trim, kebab, snake and replace.
+# Leave empty to name profiles after the account.
+# profile_template = "{{ .AccountName | lower | kebab }}-{{ .RoleName }}"
+profile_template = ""
+
+# Rules selecting the accounts and roles profiles are generated for, written
+# as [field:]pattern where field is account (default), id or role and the
+# pattern is a glob or a /regular expression/.
+# include = ["prod-*", "role:ReadOnly*"]
+# exclude = ["sandbox-*", "id:123456789012"]
+include = []
+exclude = []
+
+# Format of generated profiles: "legacy" repeats sso_start_url and sso_region
+# in every profile, "sso-session" writes a single [sso-session] section that
+# every profile references and is required for refreshable logins.
+profile_format = "legacy"
+
+# Remove previously generated profiles which are no longer generated.
+# Hand-written profiles are never removed.
+prune = false
+
+# Show what generate would change without writing the AWS config file.
+dry_run = false
+
+# Ask for confirmation before writing the AWS config file.
+confirm = false
+
+# How regenerated values are merged into existing profiles: "overwrite" sets
+# every generated key, "preserve-existing" only adds keys which are missing and
+# "only-managed-keys" keeps the SSO keys up to date while leaving keys such as
+# region or output alone once they are set.
+merge_policy = "overwrite"
+
+# Write credential_process = aws-sso-config credential-process --profile <name>
+# into generated profiles, for tools which do not support SSO profiles.
+credential_process = false
+`
+}
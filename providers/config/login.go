@@ -0,0 +1,61 @@
+package config
+
+import "fmt"
+
+// Login flows
+const (
+	// LoginFlowDeviceCode logs in by entering a code shown by the CLI on the AWS login page
+	LoginFlowDeviceCode = "device-code"
+	// LoginFlowPKCE logs in with the authorization code grant and PKCE, redirecting
+	// the browser back to a listener on the loopback interface
+	LoginFlowPKCE = "pkce"
+)
+
+// LoginConfig holds the configuration of the SSO login flow
+type LoginConfig struct {
+	Headless bool   `mapstructure:"headless" toml:"headless"`
+	Flow     string `mapstructure:"flow" toml:"flow"`
+}
+
+// DefaultLogin returns the default login configuration
+func DefaultLogin() LoginConfig {
+	return LoginConfig{
+		Headless: false,
+		Flow:     LoginFlowDeviceCode,
+	}
+}
+
+// Validate validates the login configuration
+func (l *LoginConfig) Validate() error {
+	switch l.Flow {
+	case "", LoginFlowDeviceCode, LoginFlowPKCE:
+	default:
+		return fmt.Errorf("login flow must be %q or %q", LoginFlowDeviceCode, LoginFlowPKCE)
+	}
+	return nil
+}
+
+// SetDefaults sets default values for any missing login configuration
+func (l *LoginConfig) SetDefaults() {
+	if l.Flow == "" {
+		l.Flow = LoginFlowDeviceCode
+	}
+}
+
+// GetSectionName returns the TOML section name for login configuration
+func (l *LoginConfig) GetSectionName() string {
+	return "login"
+}
+
+// GetDefaultContent returns the default TOML content for login section
+func (l *LoginConfig) GetDefaultContent() string {
+	return `# Login Configuration
+[login]
+# Print the verification URL and code instead of opening a browser. Logins are
+# headless automatically when no display is available.
+headless = false
+# Login flow: "device-code", or "pkce" to log in with the authorization code
+# grant and a redirect to 127.0.0.1. Headless logins always use the device code.
+flow = "device-code"
+`
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -72,6 +73,20 @@ func (cm *ConfigManager) Load() (*Config, error) {
 		}
 	}
 
+	// Load generate section
+	if generateData := v.Sub("generate"); generateData != nil {
+		if err := generateData.Unmarshal(&config.Generate); err != nil {
+			return nil, fmt.Errorf("error unmarshaling generate config: %w", err)
+		}
+	}
+
+	// Load login section
+	if loginData := v.Sub("login"); loginData != nil {
+		if err := loginData.Unmarshal(&config.Login); err != nil {
+			return nil, fmt.Errorf("error unmarshaling login config: %w", err)
+		}
+	}
+
 	// Set defaults for any missing values
 	config.SetDefaults()
 
@@ -89,8 +104,10 @@ func (cm *ConfigManager) createDefaultConfig() error {
 	// Combine default content from all sections
 	sso := DefaultSSO()
 	aws := DefaultAWS()
+	generate := DefaultGenerate()
+	login := DefaultLogin()
 
-	content := sso.GetDefaultContent() + "\n" + aws.GetDefaultContent()
+	content := sso.GetDefaultContent() + "\n" + aws.GetDefaultContent() + "\n" + generate.GetDefaultContent() + "\n" + login.GetDefaultContent()
 
 	return os.WriteFile(cm.configFile, []byte(content), 0600)
 }
@@ -117,6 +134,15 @@ func (cm *ConfigManager) SaveProviderConfig(provider string, data interface{}) e
 			if ssoData.Role != "" {
 				v.Set("sso.role", ssoData.Role)
 			}
+			if ssoData.SessionName != "" {
+				v.Set("sso.session_name", ssoData.SessionName)
+			}
+			if ssoData.RegistrationScopes != "" {
+				v.Set("sso.registration_scopes", ssoData.RegistrationScopes)
+			}
+			if ssoData.TokenExpirySkew != "" {
+				v.Set("sso.token_expiry_skew", ssoData.TokenExpirySkew)
+			}
 		}
 	case "aws":
 		if awsData, ok := data.(AWSConfig); ok {
@@ -127,6 +153,30 @@ func (cm *ConfigManager) SaveProviderConfig(provider string, data interface{}) e
 				v.Set("aws.config_file", awsData.ConfigFile)
 			}
 		}
+	case "generate":
+		if generateData, ok := data.(GenerateConfig); ok {
+			v.Set("generate.all_roles", generateData.AllRoles)
+			v.Set("generate.profile_template", generateData.ProfileTemplate)
+			v.Set("generate.include", generateData.Include)
+			v.Set("generate.exclude", generateData.Exclude)
+			if generateData.ProfileFormat != "" {
+				v.Set("generate.profile_format", generateData.ProfileFormat)
+			}
+			v.Set("generate.prune", generateData.Prune)
+			v.Set("generate.dry_run", generateData.DryRun)
+			v.Set("generate.confirm", generateData.Confirm)
+			if generateData.MergePolicy != "" {
+				v.Set("generate.merge_policy", generateData.MergePolicy)
+			}
+			v.Set("generate.credential_process", generateData.CredentialProcess)
+		}
+	case "login":
+		if loginData, ok := data.(LoginConfig); ok {
+			v.Set("login.headless", loginData.Headless)
+			if loginData.Flow != "" {
+				v.Set("login.flow", loginData.Flow)
+			}
+		}
 	default:
 		return fmt.Errorf("unknown provider: %s", provider)
 	}
@@ -160,6 +210,10 @@ func LoadConfigForKey(configFile string, key string) (*Config, error) {
 			config.SSO = DefaultSSO()
 		} else if strings.HasPrefix(key, "aws.") {
 			config.AWS = DefaultAWS()
+		} else if strings.HasPrefix(key, "generate.") {
+			config.Generate = DefaultGenerate()
+		} else if strings.HasPrefix(key, "login.") {
+			config.Login = DefaultLogin()
 		} else {
 			return nil, fmt.Errorf("unknown key prefix for key: %s", key)
 		}
@@ -181,3 +235,16 @@ func Load(configPath string) (*Config, error) {
 
 	return cm.Load()
 }
+
+// LoadOrDefault loads configuration from configPath, or from ~/.awsssoconfig when no
+// path is given. The defaults are used when ~/.awsssoconfig does not exist, without
+// creating it.
+func LoadOrDefault(configPath string) (*Config, error) {
+	cm := NewConfigManager(configPath)
+	if configPath == "" {
+		if _, err := os.Stat(cm.configFile); errors.Is(err, os.ErrNotExist) {
+			return Default(), nil
+		}
+	}
+	return cm.Load()
+}
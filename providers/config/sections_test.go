@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -12,6 +13,9 @@ func TestSSOConfig(t *testing.T) {
 		assert.Equal(t, "https://your-sso-portal.awsapps.com/start", sso.StartURL)
 		assert.Equal(t, "us-east-1", sso.Region)
 		assert.Equal(t, "AdministratorAccess", sso.Role)
+		assert.Equal(t, "aws-sso-config", sso.SessionName)
+		assert.Equal(t, "sso:account:access", sso.RegistrationScopes)
+		assert.Equal(t, 5*time.Minute, sso.ExpirySkew())
 	})
 
 	t.Run("SSO validation passes with valid config", func(t *testing.T) {
@@ -63,6 +67,25 @@ func TestSSOConfig(t *testing.T) {
 		assert.Equal(t, "AdministratorAccess", sso.Role) // default filled in
 	})
 
+	t.Run("SSO validation fails with invalid session name", func(t *testing.T) {
+		sso := DefaultSSO()
+		sso.SessionName = "my org"
+		err := sso.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "SSO session name")
+	})
+
+	t.Run("SSO validation fails with invalid token expiry skew", func(t *testing.T) {
+		for _, skew := range []string{"soon", "-5m"} {
+			sso := DefaultSSO()
+			sso.TokenExpirySkew = skew
+			err := sso.Validate()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "token expiry skew")
+			assert.Zero(t, sso.ExpirySkew())
+		}
+	})
+
 	t.Run("SSO GetSectionName returns correct name", func(t *testing.T) {
 		sso := SSOConfig{}
 		assert.Equal(t, "sso", sso.GetSectionName())
@@ -75,6 +98,9 @@ func TestSSOConfig(t *testing.T) {
 		assert.Contains(t, content, `start_url = "https://your-sso-portal.awsapps.com/start"`)
 		assert.Contains(t, content, `region = "us-east-1"`)
 		assert.Contains(t, content, `role = "AdministratorAccess"`)
+		assert.Contains(t, content, `session_name = "aws-sso-config"`)
+		assert.Contains(t, content, `registration_scopes = "sso:account:access"`)
+		assert.Contains(t, content, `token_expiry_skew = "5m"`)
 	})
 }
 
@@ -141,3 +167,94 @@ func TestAWSConfig(t *testing.T) {
 		assert.Contains(t, content, `config_file = "~/.aws/config"`)
 	})
 }
+
+func TestGenerateConfig(t *testing.T) {
+	t.Run("DefaultGenerate returns valid defaults", func(t *testing.T) {
+		generate := DefaultGenerate()
+		assert.False(t, generate.AllRoles)
+		assert.Empty(t, generate.ProfileTemplate)
+		assert.Equal(t, ProfileFormatLegacy, generate.ProfileFormat)
+		assert.False(t, generate.Prune)
+		assert.False(t, generate.DryRun)
+		assert.False(t, generate.Confirm)
+		assert.Equal(t, MergePolicyOverwrite, generate.MergePolicy)
+		assert.NoError(t, generate.Validate())
+	})
+
+	t.Run("Generate GetSectionName returns correct name", func(t *testing.T) {
+		generate := GenerateConfig{}
+		assert.Equal(t, "generate", generate.GetSectionName())
+	})
+
+	t.Run("Generate GetDefaultContent returns valid TOML", func(t *testing.T) {
+		generate := GenerateConfig{}
+		content := generate.GetDefaultContent()
+		assert.Contains(t, content, "[generate]")
+		assert.Contains(t, content, "all_roles = false")
+		assert.Contains(t, content, `profile_template = ""`)
+		assert.Contains(t, content, `profile_format = "legacy"`)
+		assert.Contains(t, content, "prune = false")
+		assert.Contains(t, content, "dry_run = false")
+		assert.Contains(t, content, "confirm = false")
+		assert.Contains(t, content, `merge_policy = "overwrite"`)
+	})
+
+	t.Run("Generate validation fails with unknown profile format", func(t *testing.T) {
+		generate := DefaultGenerate()
+		generate.ProfileFormat = "modern"
+		err := generate.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "profile format")
+	})
+
+	t.Run("Generate validation fails with unknown merge policy", func(t *testing.T) {
+		generate := DefaultGenerate()
+		generate.MergePolicy = "merge"
+		err := generate.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "merge policy")
+	})
+
+	t.Run("Generate SetDefaults sets missing profile format", func(t *testing.T) {
+		generate := GenerateConfig{}
+		generate.SetDefaults()
+		assert.Equal(t, ProfileFormatLegacy, generate.ProfileFormat)
+		assert.Equal(t, MergePolicyOverwrite, generate.MergePolicy)
+	})
+}
+
+func TestLoginConfig(t *testing.T) {
+	t.Run("DefaultLogin returns valid defaults", func(t *testing.T) {
+		login := DefaultLogin()
+		assert.False(t, login.Headless)
+		assert.Equal(t, LoginFlowDeviceCode, login.Flow)
+		assert.NoError(t, login.Validate())
+	})
+
+	t.Run("Login GetSectionName returns correct name", func(t *testing.T) {
+		login := LoginConfig{}
+		assert.Equal(t, "login", login.GetSectionName())
+	})
+
+	t.Run("Login GetDefaultContent returns valid TOML", func(t *testing.T) {
+		login := LoginConfig{}
+		content := login.GetDefaultContent()
+		assert.Contains(t, content, "[login]")
+		assert.Contains(t, content, "headless = false")
+		assert.Contains(t, content, `flow = "device-code"`)
+	})
+
+	t.Run("Login validation fails with unknown flow", func(t *testing.T) {
+		login := DefaultLogin()
+		login.Flow = "implicit"
+		err := login.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "login flow")
+	})
+
+	t.Run("Login SetDefaults sets missing flow", func(t *testing.T) {
+		login := LoginConfig{}
+		login.SetDefaults()
+		assert.Equal(t, LoginFlowDeviceCode, login.Flow)
+	})
+}
@@ -1,12 +1,25 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // SSOConfig holds SSO-specific configuration
 type SSOConfig struct {
 	StartURL string `mapstructure:"start_url" toml:"start_url"`
 	Region   string `mapstructure:"region" toml:"region"`
 	Role     string `mapstructure:"role" toml:"role"`
+
+	// SessionName names the [sso-session] section written when generating
+	// profiles in the sso-session format
+	SessionName string `mapstructure:"session_name" toml:"session_name"`
+	// RegistrationScopes is a comma-separated list of scopes requested for the session
+	RegistrationScopes string `mapstructure:"registration_scopes" toml:"registration_scopes"`
+	// TokenExpirySkew is how long before it expires a cached token is no longer
+	// used, written as a duration such as "5m"
+	TokenExpirySkew string `mapstructure:"token_expiry_skew" toml:"token_expiry_skew"`
 }
 
 // DefaultSSO returns the default SSO configuration
@@ -15,6 +28,10 @@ func DefaultSSO() SSOConfig {
 		StartURL: "https://your-sso-portal.awsapps.com/start",
 		Region:   "us-east-1",
 		Role:     "AdministratorAccess",
+
+		SessionName:        "aws-sso-config",
+		RegistrationScopes: "sso:account:access",
+		TokenExpirySkew:    "5m",
 	}
 }
 
@@ -26,9 +43,26 @@ func (s *SSOConfig) Validate() error {
 	if s.Region == "" {
 		return fmt.Errorf("SSO region is required")
 	}
+	if strings.ContainsAny(s.SessionName, "[] \t\r\n") {
+		return fmt.Errorf("SSO session name %q must not contain whitespace or brackets", s.SessionName)
+	}
+	if s.TokenExpirySkew != "" {
+		if skew, err := time.ParseDuration(s.TokenExpirySkew); err != nil || skew < 0 {
+			return fmt.Errorf("SSO token expiry skew %q must be a non-negative duration such as 5m", s.TokenExpirySkew)
+		}
+	}
 	return nil
 }
 
+// ExpirySkew returns the token expiry skew, or zero when it is not set
+func (s *SSOConfig) ExpirySkew() time.Duration {
+	skew, err := time.ParseDuration(s.TokenExpirySkew)
+	if err != nil || skew < 0 {
+		return 0
+	}
+	return skew
+}
+
 // SetDefaults sets default values for any missing SSO configuration
 func (s *SSOConfig) SetDefaults() {
 	if s.StartURL == "" {
@@ -40,6 +74,15 @@ func (s *SSOConfig) SetDefaults() {
 	if s.Role == "" {
 		s.Role = "AdministratorAccess"
 	}
+	if s.SessionName == "" {
+		s.SessionName = "aws-sso-config"
+	}
+	if s.RegistrationScopes == "" {
+		s.RegistrationScopes = "sso:account:access"
+	}
+	if s.TokenExpirySkew == "" {
+		s.TokenExpirySkew = "5m"
+	}
 }
 
 // GetSectionName returns the TOML section name for SSO configuration
@@ -54,5 +97,9 @@ func (s *SSOConfig) GetDefaultContent() string {
 start_url = "https://your-sso-portal.awsapps.com/start"
 region = "us-east-1"
 role = "AdministratorAccess"
+session_name = "aws-sso-config"
+registration_scopes = "sso:account:access"
+# Cached tokens expiring within this duration are not used
+token_expiry_skew = "5m"
 `
 }